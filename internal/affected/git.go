@@ -0,0 +1,81 @@
+package affected
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ChangedFiles returns the absolute paths of all files changed on HEAD since it branched off baseRef, like
+// git diff baseRef...HEAD. The repository containing path is read from the local .git directory, nothing is fetched.
+func ChangedFiles(path, baseRef string) ([]string, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to open git repository at %s", path), err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to open git worktree"), err)
+	}
+
+	base, err := resolveCommit(repo, baseRef)
+	if err != nil {
+		return nil, err
+	}
+	head, err := resolveCommit(repo, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	mergeBases, err := base.MergeBase(head)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to determine merge base of %s and HEAD", baseRef), err)
+	}
+	if len(mergeBases) == 0 {
+		return nil, fmt.Errorf("%s and HEAD have no common history", baseRef)
+	}
+
+	baseTree, err := mergeBases[0].Tree()
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read tree of the merge base"), err)
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read tree of HEAD"), err)
+	}
+
+	changes, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to diff %s against HEAD", baseRef), err)
+	}
+
+	root := worktree.Filesystem.Root()
+	var files []string
+	for _, change := range changes {
+		// Renames show up with both names, deletions only with the old one
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" {
+				files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+			}
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+func resolveCommit(repo *git.Repository, ref string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to resolve git ref %s", ref), err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to read commit %s", ref), err)
+	}
+	return commit, nil
+}
@@ -0,0 +1,104 @@
+package affected
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-cmp/cmp"
+)
+
+func commitFiles(t *testing.T, worktree *git.Worktree, root string, files map[string]string, removed ...string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range removed {
+		if _, err := worktree.Remove(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := worktree.Commit("change", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commitFiles(t, worktree, root, map[string]string{
+		"hive.yml":                    "",
+		"images/python/image.yml":     "tags: []",
+		"images/dotnet/8/Dockerfile":  "FROM scratch",
+		"images/ubuntu/rootfs/motd":   "hello",
+		"images/ubuntu/Dockerfile":    "FROM ubuntu",
+		"images/ubuntu/image.yml":     "tags: []",
+		"images/node/24/Dockerfile":   "FROM node",
+		"images/dotnet/8/rootfs/info": "v1",
+	})
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/base", head.Hash())); err != nil {
+		t.Fatal(err)
+	}
+
+	commitFiles(t, worktree, root, map[string]string{
+		"images/dotnet/8/rootfs/info": "v2",
+		"images/python/Dockerfile":    "FROM python",
+	}, "images/ubuntu/rootfs/motd")
+
+	// Changes on the base branch after branching off are not part of the diff
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: "refs/heads/base"}); err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, worktree, root, map[string]string{"images/node/24/Dockerfile": "FROM node:24"})
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: "refs/heads/master"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ChangedFiles(filepath.Join(root, "images"), "base")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(root, "images", "dotnet", "8", "rootfs", "info"),
+		filepath.Join(root, "images", "python", "Dockerfile"),
+		filepath.Join(root, "images", "ubuntu", "rootfs", "motd"),
+	}
+	if diff := cmp.Diff(expected, files); diff != "" {
+		t.Errorf("ChangedFiles() mismatch (-expected +got):\n%s", diff)
+	}
+
+	t.Run("unknown ref", func(t *testing.T) {
+		if _, err := ChangedFiles(root, "does-not-exist"); err == nil {
+			t.Error("expected error for unknown ref")
+		}
+	})
+}
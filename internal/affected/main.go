@@ -0,0 +1,75 @@
+package affected
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+// Images returns the images affected by the changed files sorted by identifier.
+//
+// A change to the project config affects all images. Files inside the directory of an image, which contains its
+// definition, Dockerfile, rootfs, tests and variant directories, affect that image, or the innermost image if
+// image directories are nested. Other files below the images
+// directory affect all images in their closest parent directory containing images, e.g. images/dotnet/rootfs affects
+// dotnet/8. All other files are ignored.
+func Images(project *model.ContainerHiveProject, changedFiles []string) []*model.Image {
+	imagesDir := filepath.Join(project.RootDir, "images")
+	affected := make(map[string]*model.Image)
+	for _, file := range changedFiles {
+		file = filepath.Clean(file)
+		if file == filepath.Clean(project.ConfigFilePath) {
+			maps.Copy(affected, project.ImagesByIdentifier)
+			continue
+		}
+		maps.Copy(affected, imagesForFile(project, imagesDir, file))
+	}
+
+	images := slices.Collect(maps.Values(affected))
+	slices.SortFunc(images, func(a, b *model.Image) int {
+		return strings.Compare(a.Identifier, b.Identifier)
+	})
+	return images
+}
+
+func imagesForFile(project *model.ContainerHiveProject, imagesDir, file string) map[string]*model.Image {
+	// Images can be nested in the directory of another image, the closest one owns the file
+	var owner *model.Image
+	for _, image := range project.ImagesByIdentifier {
+		if !isWithin(file, image.RootDir) {
+			continue
+		}
+		if owner == nil || len(filepath.Clean(image.RootDir)) > len(filepath.Clean(owner.RootDir)) {
+			owner = image
+		}
+	}
+	if owner != nil {
+		return map[string]*model.Image{owner.Identifier: owner}
+	}
+
+	// Walk up until a directory contains images, files directly inside the images directory affect nothing
+	for dir := filepath.Dir(file); isWithin(dir, imagesDir) && dir != imagesDir; dir = filepath.Dir(dir) {
+		images := make(map[string]*model.Image)
+		for identifier, image := range project.ImagesByIdentifier {
+			if isWithin(image.RootDir, dir) {
+				images[identifier] = image
+			}
+		}
+		if len(images) > 0 {
+			return images
+		}
+	}
+	return nil
+}
+
+// isWithin reports whether path is dir itself or located below it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
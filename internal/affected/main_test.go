@@ -0,0 +1,119 @@
+package affected
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/pkg/discovery"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+func TestImages(t *testing.T) {
+	project, err := discovery.DiscoverProject(t.Context(), "../../pkg/testdata/simple-project")
+	if err != nil {
+		t.Fatalf("failed to discover project: %v", err)
+	}
+	path := func(rel string) string {
+		return filepath.Join(project.RootDir, filepath.FromSlash(rel))
+	}
+
+	testCases := []struct {
+		name     string
+		files    []string
+		expected []string
+	}{
+		{
+			name:     "no changes",
+			expected: []string{},
+		},
+		{
+			name:     "image definition",
+			files:    []string{path("images/python/image.yml")},
+			expected: []string{"python"},
+		},
+		{
+			name:     "nested image rootfs",
+			files:    []string{path("images/dotnet/8/rootfs/opt/acme-corp/info")},
+			expected: []string{"dotnet/8"},
+		},
+		{
+			name:     "variant directory",
+			files:    []string{path("images/dotnet/8/node/test.yml.gotpl")},
+			expected: []string{"dotnet/8"},
+		},
+		{
+			name:     "parent directory of a nested image",
+			files:    []string{path("images/dotnet/rootfs/opt/acme-corp/info")},
+			expected: []string{"dotnet/8"},
+		},
+		{
+			name:     "project config",
+			files:    []string{path("hive.yml")},
+			expected: []string{"dotnet/8", "python"},
+		},
+		{
+			name:     "unrelated files",
+			files:    []string{path("README.md"), path("docs/images/python.md")},
+			expected: []string{},
+		},
+		{
+			name:     "file directly in the images directory",
+			files:    []string{path("images/README.md")},
+			expected: []string{},
+		},
+		{
+			name:     "deleted image",
+			files:    []string{path("images/ruby/image.yml")},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := []string{}
+			for _, image := range Images(project, tc.files) {
+				got = append(got, image.Identifier)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("Images() mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestImages_NestedImageDirectories(t *testing.T) {
+	root := t.TempDir()
+	images := map[string]*model.Image{}
+	for _, identifier := range []string{"dotnet", "dotnet/8", "dotnet/8/alpine"} {
+		images[identifier] = &model.Image{
+			Identifier: identifier,
+			RootDir:    filepath.Join(root, "images", filepath.FromSlash(identifier)),
+		}
+	}
+	project := &model.ContainerHiveProject{
+		RootDir:            root,
+		ConfigFilePath:     filepath.Join(root, "hive.yml"),
+		ImagesByIdentifier: images,
+	}
+
+	testCases := map[string]string{
+		"images/dotnet/image.yml":               "dotnet",
+		"images/dotnet/8/Dockerfile":            "dotnet/8",
+		"images/dotnet/8/rootfs/etc/motd":       "dotnet/8",
+		"images/dotnet/8/alpine/image.yml":      "dotnet/8/alpine",
+		"images/dotnet/8/alpine/rootfs/etc/foo": "dotnet/8/alpine",
+	}
+
+	for file, expected := range testCases {
+		t.Run(file, func(t *testing.T) {
+			// Map iteration order is random, the innermost image has to win every time
+			for range 20 {
+				got := Images(project, []string{filepath.Join(root, filepath.FromSlash(file))})
+				if len(got) != 1 || got[0].Identifier != expected {
+					t.Fatalf("expected only %s to be affected, got %v", expected, got)
+				}
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package buildconfig_resolver
 
 import (
 	"fmt"
+	"maps"
 
+	"github.com/timo-reymann/ContainerHive/internal/file_resolver/templating"
 	"github.com/timo-reymann/ContainerHive/internal/secrets"
 	"github.com/timo-reymann/ContainerHive/pkg/model"
 )
@@ -11,12 +13,32 @@ type ResolvedBuildValues struct {
 	BuildArgs model.BuildArgs
 	Versions  model.Versions
 	Secrets   map[string][]byte
+	// Labels and Annotations are rendered already, nil if none are configured
+	Labels      model.Labels
+	Annotations model.Annotations
 }
 
 func ForTag(image *model.Image, tag *model.Tag) (*ResolvedBuildValues, error) {
+	resolved := ArgsForTag(image, tag)
+	if err := resolved.resolveTagSecrets(image, tag); err != nil {
+		return nil, err
+	}
+
+	// Tag labels and annotations override image ones with the same name
+	if err := resolved.renderMetadata(image, mergeMaps(image.Labels, tag.Labels), mergeMaps(image.Annotations, tag.Annotations)); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// ArgsForTag merges the versions and build args of the tag without resolving secrets and metadata,
+// e.g. to render templates or expand ARGs in Dockerfiles.
+func ArgsForTag(image *model.Image, tag *model.Tag) *ResolvedBuildValues {
+	// Clone the maps, as tags and variants of the same image are resolved concurrently
 	resolved := &ResolvedBuildValues{
-		BuildArgs: tag.BuildArgs,
-		Versions:  image.Versions,
+		BuildArgs: maps.Clone(image.DefaultBuildArgs),
+		Versions:  maps.Clone(image.Versions),
 		Secrets:   make(map[string][]byte),
 	}
 
@@ -32,27 +54,20 @@ func ForTag(image *model.Image, tag *model.Tag) (*ResolvedBuildValues, error) {
 		resolved.Versions[k] = v
 	}
 
-	for k, v := range image.BuildArgs {
+	for k, v := range tag.BuildArgs {
 		resolved.BuildArgs[k] = v
 	}
 
-	// Resolve secrets
-	for k, secret := range image.Secrets {
-		resolvedValue, err := secrets.Resolve(secret.SourceType, secret.Value)
-		if err != nil {
-			return nil, fmt.Errorf("failed to resolve secret '%s': %w", k, err)
-		}
-		resolved.Secrets[k] = []byte(resolvedValue)
+	for k, v := range image.BuildArgs {
+		resolved.BuildArgs[k] = v
 	}
 
-	return resolved, nil
+	return resolved
 }
 
-func ForTagVariant(image *model.Image, variant *model.ImageVariant, tag *model.Tag) (*ResolvedBuildValues, error) {
-	resolved, err := ForTag(image, tag)
-	if err != nil {
-		return nil, err
-	}
+// ArgsForTagVariant merges the versions and build args of the variant without resolving secrets and metadata.
+func ArgsForTagVariant(image *model.Image, variant *model.ImageVariant, tag *model.Tag) *ResolvedBuildValues {
+	resolved := ArgsForTag(image, tag)
 
 	for k, v := range variant.Versions {
 		resolved.Versions[k] = v
@@ -62,9 +77,82 @@ func ForTagVariant(image *model.Image, variant *model.ImageVariant, tag *model.T
 		resolved.BuildArgs[k] = v
 	}
 
+	return resolved
+}
+
+func ForTagVariant(image *model.Image, variant *model.ImageVariant, tag *model.Tag) (*ResolvedBuildValues, error) {
+	resolved := ArgsForTagVariant(image, variant, tag)
+	if err := resolved.resolveTagSecrets(image, tag); err != nil {
+		return nil, err
+	}
+
+	// Variant secrets are only passed to variant builds, never to the plain tag
+	if err := resolved.resolveSecrets(variant.Secrets); err != nil {
+		return nil, err
+	}
+
+	labels := mergeMaps(image.Labels, tag.Labels, variant.Labels)
+	annotations := mergeMaps(image.Annotations, tag.Annotations, variant.Annotations)
+	if err := resolved.renderMetadata(image, labels, annotations); err != nil {
+		return nil, err
+	}
+
 	return resolved, nil
 }
 
+// resolveTagSecrets resolves the image and tag secrets, tag secrets override image secrets with the same name.
+func (r *ResolvedBuildValues) resolveTagSecrets(image *model.Image, tag *model.Tag) error {
+	if err := r.resolveSecrets(image.Secrets); err != nil {
+		return err
+	}
+	return r.resolveSecrets(tag.Secrets)
+}
+
+// resolveSecrets resolves the secrets and adds them, replacing already resolved secrets with the same name.
+func (r *ResolvedBuildValues) resolveSecrets(secretDefs model.Secrets) error {
+	for k, secret := range secretDefs {
+		resolvedValue, err := secrets.Resolve(secret.SourceType, secret.Value)
+		if err != nil {
+			return fmt.Errorf("failed to resolve secret '%s': %w", k, err)
+		}
+		r.Secrets[k] = []byte(resolvedValue)
+	}
+	return nil
+}
+
+// renderMetadata renders the label and annotation values as templates with the resolved versions and build args.
+func (r *ResolvedBuildValues) renderMetadata(image *model.Image, labels model.Labels, annotations model.Annotations) error {
+	tplCtx := &templating.TemplateContext{
+		ImageName: image.Name,
+		Versions:  r.Versions,
+		BuildArgs: r.BuildArgs,
+	}
+
+	var err error
+	if r.Labels, err = renderValues(tplCtx, "label", labels); err != nil {
+		return err
+	}
+	r.Annotations, err = renderValues(tplCtx, "annotation", annotations)
+	return err
+}
+
+func renderValues[M ~map[string]string](tplCtx *templating.TemplateContext, kind string, values M) (M, error) {
+	if values == nil {
+		return nil, nil
+	}
+
+	processor := &templating.GoTemplateTemplatingProcessor{}
+	rendered := make(M, len(values))
+	for k, v := range values {
+		out, err := processor.Process(tplCtx, kind+" "+k, []byte(v))
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s '%s': %w", kind, k, err)
+		}
+		rendered[k] = string(out)
+	}
+	return rendered, nil
+}
+
 func (r *ResolvedBuildValues) ToBuildArgs() model.BuildArgs {
 	var buildArgs = map[string]string{}
 
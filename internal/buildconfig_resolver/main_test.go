@@ -128,6 +128,45 @@ func TestForTag(t *testing.T) {
 				Secrets:  map[string][]byte{},
 			},
 		},
+		"tag build args override project build args": {
+			image: &model.Image{
+				DefaultBuildArgs: model.BuildArgs{
+					"VENDOR":   "acme-corp",
+					"BASE_URL": "https://example.com",
+				},
+			},
+			tag: &model.Tag{
+				BuildArgs: model.BuildArgs{
+					"VENDOR": "tag-vendor",
+				},
+			},
+			expected: &ResolvedBuildValues{
+				BuildArgs: model.BuildArgs{
+					"VENDOR":   "tag-vendor",          // tag overrides project
+					"BASE_URL": "https://example.com", // from project
+				},
+				Versions: model.Versions{},
+				Secrets:  map[string][]byte{},
+			},
+		},
+		"image build args override project build args": {
+			image: &model.Image{
+				DefaultBuildArgs: model.BuildArgs{
+					"VENDOR": "acme-corp",
+				},
+				BuildArgs: model.BuildArgs{
+					"VENDOR": "image-vendor",
+				},
+			},
+			tag: &model.Tag{},
+			expected: &ResolvedBuildValues{
+				BuildArgs: model.BuildArgs{
+					"VENDOR": "image-vendor", // image overrides project
+				},
+				Versions: model.Versions{},
+				Secrets:  map[string][]byte{},
+			},
+		},
 		"complex merge scenario": {
 			image: &model.Image{
 				Versions: model.Versions{
@@ -179,6 +218,28 @@ func TestForTag(t *testing.T) {
 	}
 }
 
+func TestForTag_DoesNotMutateInputs(t *testing.T) {
+	image := &model.Image{
+		Versions:  model.Versions{"python": "3.10"},
+		BuildArgs: model.BuildArgs{"BASE_IMAGE": "alpine:3.18"},
+	}
+	tag := &model.Tag{
+		Versions:  model.Versions{"python": "3.11"},
+		BuildArgs: model.BuildArgs{"BUILD_TYPE": "release"},
+	}
+
+	if _, err := ForTag(image, tag); err != nil {
+		t.Fatalf("ForTag() unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff(model.Versions{"python": "3.10"}, image.Versions); diff != "" {
+		t.Errorf("image versions were mutated (-expected +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(model.BuildArgs{"BUILD_TYPE": "release"}, tag.BuildArgs); diff != "" {
+		t.Errorf("tag build args were mutated (-expected +got):\n%s", diff)
+	}
+}
+
 func TestForTagVariant(t *testing.T) {
 	tests := map[string]struct {
 		image    *model.Image
@@ -351,6 +412,79 @@ func TestForTagVariant(t *testing.T) {
 	}
 }
 
+func TestSecrets(t *testing.T) {
+	t.Setenv("NPM_TOKEN", "npm-token")
+
+	image := &model.Image{
+		Secrets: model.Secrets{
+			"api_key": {SourceType: "plain", Value: "image-key"},
+			"token":   {SourceType: "plain", Value: "image-token"},
+		},
+	}
+	tag := &model.Tag{
+		Secrets: model.Secrets{
+			"token": {SourceType: "plain", Value: "tag-token"},
+		},
+	}
+	variant := &model.ImageVariant{
+		Secrets: model.Secrets{
+			"npm_token": {SourceType: "env", Value: "${NPM_TOKEN}"},
+		},
+	}
+
+	t.Run("tag secrets override image secrets", func(t *testing.T) {
+		got, err := ForTag(image, tag)
+		if err != nil {
+			t.Fatalf("ForTag() unexpected error: %v", err)
+		}
+		expected := map[string][]byte{
+			"api_key": []byte("image-key"),
+			"token":   []byte("tag-token"),
+		}
+		if diff := cmp.Diff(expected, got.Secrets); diff != "" {
+			t.Errorf("ForTag() secrets mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("variant secrets are added to variant builds only", func(t *testing.T) {
+		got, err := ForTagVariant(image, variant, tag)
+		if err != nil {
+			t.Fatalf("ForTagVariant() unexpected error: %v", err)
+		}
+		expected := map[string][]byte{
+			"api_key":   []byte("image-key"),
+			"token":     []byte("tag-token"),
+			"npm_token": []byte("npm-token"),
+		}
+		if diff := cmp.Diff(expected, got.Secrets); diff != "" {
+			t.Errorf("ForTagVariant() secrets mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("unresolvable variant secret fails", func(t *testing.T) {
+		broken := &model.ImageVariant{
+			Secrets: model.Secrets{"npm_token": {SourceType: "unknown", Value: "x"}},
+		}
+		if _, err := ForTagVariant(image, broken, tag); err == nil {
+			t.Fatal("ForTagVariant() expected error for unknown secret source")
+		}
+	})
+
+	t.Run("args do not resolve secrets", func(t *testing.T) {
+		broken := &model.ImageVariant{
+			Secrets:   model.Secrets{"npm_token": {SourceType: "unknown", Value: "x"}},
+			BuildArgs: model.BuildArgs{"VARIANT": "true"},
+		}
+		got := ArgsForTagVariant(image, broken, tag)
+		if len(got.Secrets) != 0 {
+			t.Errorf("ArgsForTagVariant() expected no secrets, got %v", got.Secrets)
+		}
+		if diff := cmp.Diff(model.BuildArgs{"VARIANT": "true"}, got.BuildArgs); diff != "" {
+			t.Errorf("ArgsForTagVariant() build args mismatch (-expected +got):\n%s", diff)
+		}
+	})
+}
+
 func TestToBuildArgs(t *testing.T) {
 	tests := map[string]struct {
 		resolved *ResolvedBuildValues
@@ -467,3 +601,50 @@ func TestToBuildArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestForTagVariant_Metadata(t *testing.T) {
+	image := &model.Image{
+		Name:        "python",
+		Versions:    model.Versions{"python": "3.13.7"},
+		Labels:      model.Labels{"com.example.team": "platform", "com.example.python": "{{ .Versions.python }}"},
+		Annotations: model.Annotations{"com.example.image": "{{ .ImageName }}"},
+	}
+	tag := &model.Tag{
+		Labels: model.Labels{"com.example.team": "data"},
+	}
+	variant := &model.ImageVariant{
+		Versions: model.Versions{"node": "24.1.0"},
+		Labels:   model.Labels{"com.example.node": "{{ .Versions.node }}"},
+	}
+
+	resolved, err := ForTag(image, tag)
+	if err != nil {
+		t.Fatalf("ForTag() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(model.Labels{"com.example.team": "data", "com.example.python": "3.13.7"}, resolved.Labels); diff != "" {
+		t.Errorf("ForTag() labels mismatch (-expected +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(model.Annotations{"com.example.image": "python"}, resolved.Annotations); diff != "" {
+		t.Errorf("ForTag() annotations mismatch (-expected +got):\n%s", diff)
+	}
+
+	resolved, err = ForTagVariant(image, variant, tag)
+	if err != nil {
+		t.Fatalf("ForTagVariant() unexpected error: %v", err)
+	}
+	expected := model.Labels{"com.example.team": "data", "com.example.python": "3.13.7", "com.example.node": "24.1.0"}
+	if diff := cmp.Diff(expected, resolved.Labels); diff != "" {
+		t.Errorf("ForTagVariant() labels mismatch (-expected +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(model.Labels{"com.example.team": "platform", "com.example.python": "{{ .Versions.python }}"}, image.Labels); diff != "" {
+		t.Errorf("image labels were mutated (-expected +got):\n%s", diff)
+	}
+
+	t.Run("invalid template", func(t *testing.T) {
+		image := &model.Image{Labels: model.Labels{"broken": "{{ .Versions"}}
+		if _, err := ForTag(image, &model.Tag{}); err == nil {
+			t.Error("expected error for invalid label template")
+		}
+	})
+}
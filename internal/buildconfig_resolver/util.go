@@ -1,6 +1,9 @@
 package buildconfig_resolver
 
-import "strings"
+import (
+	"maps"
+	"strings"
+)
 
 func normalizeKey(k string) string {
 	result := make([]rune, 0, len(k))
@@ -13,3 +16,18 @@ func normalizeKey(k string) string {
 	}
 	return strings.ToUpper(string(result))
 }
+
+// mergeMaps merges the maps into a new one, later maps take precedence. Returns nil if all maps are empty.
+func mergeMaps[M ~map[string]string](ms ...M) M {
+	var merged M
+	for _, m := range ms {
+		if len(m) == 0 {
+			continue
+		}
+		if merged == nil {
+			merged = make(M)
+		}
+		maps.Copy(merged, m)
+	}
+	return merged
+}
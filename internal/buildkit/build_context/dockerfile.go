@@ -1,8 +1,11 @@
 package build_context
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,26 +13,33 @@ import (
 	"github.com/moby/buildkit/frontend/dockerfile/builder"
 	gatewayClient "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/tonistiigi/fsutil"
+	"github.com/tonistiigi/fsutil/types"
 )
 
 var defaultDockerfile = "Dockerfile"
 
 const hivePrefix = "__hive__/"
 
-// RewriteHiveRefs replaces all __hive__/ prefixes in a Dockerfile with the actual registry address.
-func RewriteHiveRefs(src, target string, registryAddress string) error {
-	content, err := os.ReadFile(src)
-	if err != nil {
-		return errors.Join(errors.New("failed to read Dockerfile for rewriting"), err)
-	}
+// HiveContextPrefix replaces __hive__/ when bases are passed as named contexts, as __hive__ is no valid image name.
+const HiveContextPrefix = "containerhive.local/"
+
+// HiveContextName returns the named context a __hive__/<name>:<tag> reference resolves to after rewriting it with
+// HiveContextPrefix. Like the dockerfile frontend it drops the latest tag.
+func HiveContextName(key string) string {
+	return strings.TrimSuffix(HiveContextPrefix+key, ":latest")
+}
 
-	replaced := strings.ReplaceAll(string(content), hivePrefix, registryAddress+"/")
-	return os.WriteFile(target, []byte(replaced), 0644)
+// RewriteHiveRefs replaces all __hive__/ prefixes in Dockerfile content with the prefix, e.g. a registry address
+// followed by a slash.
+func RewriteHiveRefs(content []byte, prefix string) []byte {
+	return bytes.ReplaceAll(content, []byte(hivePrefix), []byte(prefix))
 }
 
 type DockerfileBuildContext struct {
 	Root       string
 	Dockerfile string
+	// HivePrefix replaces __hive__/ in the Dockerfile sent to buildkit, the file on disk is left untouched
+	HivePrefix string
 }
 
 func (d DockerfileBuildContext) RunBuild(ctx context.Context, client gatewayClient.Client) (*gatewayClient.Result, error) {
@@ -65,8 +75,53 @@ func (d DockerfileBuildContext) ToLocalMounts() (map[string]fsutil.FS, error) {
 		return nil, errors.Join(errors.New("invalid Dockerfile path"), err)
 	}
 
+	if d.HivePrefix != "" {
+		content, err := os.ReadFile(dockerFilePath)
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to read Dockerfile"), err)
+		}
+		dockerfileFs = &rewrittenFS{
+			FS:      dockerfileFs,
+			name:    filepath.Base(dockerFilePath),
+			content: RewriteHiveRefs(content, d.HivePrefix),
+		}
+	}
+
 	return map[string]fsutil.FS{
 		"context":    ctxFs,
 		"dockerfile": dockerfileFs,
 	}, nil
 }
+
+// rewrittenFS serves a file with replaced content and everything else from the underlying FS.
+type rewrittenFS struct {
+	fsutil.FS
+	name    string
+	content []byte
+}
+
+func (r *rewrittenFS) Open(p string) (io.ReadCloser, error) {
+	if p == r.name {
+		return io.NopCloser(bytes.NewReader(r.content)), nil
+	}
+	return r.FS.Open(p)
+}
+
+func (r *rewrittenFS) Walk(ctx context.Context, target string, fn fs.WalkDirFunc) error {
+	return r.FS.Walk(ctx, target, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || p != r.name {
+			return fn(p, entry, err)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fn(p, entry, err)
+		}
+		stat, ok := info.Sys().(*types.Stat)
+		if !ok {
+			return fn(p, entry, errors.New("unexpected file info of "+p))
+		}
+		stat = stat.Clone()
+		stat.Size = int64(len(r.content))
+		return fn(p, fs.FileInfoToDirEntry(&fsutil.StatInfo{Stat: stat}), nil)
+	})
+}
@@ -1,9 +1,10 @@
 package build_context
 
 import (
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
 	"testing"
 )
 
@@ -151,57 +152,114 @@ func TestDockerfileBuildContext_ToLocalMounts(t *testing.T) {
 }
 
 func TestRewriteHiveRefs(t *testing.T) {
-	t.Run("replaces __hive__/ with registry address", func(t *testing.T) {
-		dir := t.TempDir()
-		df := filepath.Join(dir, "Dockerfile")
-		os.WriteFile(df, []byte("FROM __hive__/ubuntu:22.04\nRUN echo hello"), 0644)
+	tests := []struct {
+		name     string
+		content  string
+		prefix   string
+		expected string
+	}{
+		{
+			name:     "replaces __hive__/ with registry address",
+			content:  "FROM __hive__/ubuntu:22.04\nRUN echo hello",
+			prefix:   "localhost:5123/",
+			expected: "FROM localhost:5123/ubuntu:22.04\nRUN echo hello",
+		},
+		{
+			name:     "replaces multiple __hive__/ references",
+			content:  "FROM __hive__/ubuntu:22.04 AS base\nFROM __hive__/node:20 AS builder\nRUN echo hello",
+			prefix:   "registry.example.com/",
+			expected: "FROM registry.example.com/ubuntu:22.04 AS base\nFROM registry.example.com/node:20 AS builder\nRUN echo hello",
+		},
+		{
+			name:     "no-op when no __hive__/ references",
+			content:  "FROM ubuntu:22.04\nRUN echo hello",
+			prefix:   "localhost:5123/",
+			expected: "FROM ubuntu:22.04\nRUN echo hello",
+		},
+	}
 
-		err := RewriteHiveRefs(df, df, "localhost:5123")
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
-		}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(RewriteHiveRefs([]byte(tt.content), tt.prefix))
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
 
-		got, _ := os.ReadFile(df)
-		expected := "FROM localhost:5123/ubuntu:22.04\nRUN echo hello"
-		if string(got) != expected {
-			t.Errorf("expected %q, got %q", expected, string(got))
+func TestHiveContextName(t *testing.T) {
+	tests := map[string]string{
+		"ubuntu:22.04":    "containerhive.local/ubuntu:22.04",
+		"dotnet/8:sdk":    "containerhive.local/dotnet/8:sdk",
+		"python:latest":   "containerhive.local/python",
+		"python:3-latest": "containerhive.local/python:3-latest",
+	}
+	for key, expected := range tests {
+		if got := HiveContextName(key); got != expected {
+			t.Errorf("HiveContextName(%q) = %q, expected %q", key, got, expected)
 		}
-	})
-
-	t.Run("replaces multiple __hive__/ references", func(t *testing.T) {
-		dir := t.TempDir()
-		df := filepath.Join(dir, "Dockerfile")
-		content := "FROM __hive__/ubuntu:22.04 AS base\nFROM __hive__/node:20 AS builder\nRUN echo hello"
-		os.WriteFile(df, []byte(content), 0644)
+	}
+}
 
-		err := RewriteHiveRefs(df, df, "registry.example.com")
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
-		}
+func TestDockerfileBuildContext_HivePrefix(t *testing.T) {
+	root := t.TempDir()
+	content := "FROM __hive__/ubuntu:22.04\nRUN echo hello\n"
+	if err := os.WriteFile(filepath.Join(root, "Dockerfile"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "Dockerfile.dockerignore"), []byte("*.tar\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
 
-		got, _ := os.ReadFile(df)
-		if !strings.Contains(string(got), "FROM registry.example.com/ubuntu:22.04") {
-			t.Errorf("expected replaced ubuntu ref, got %q", string(got))
-		}
-		if !strings.Contains(string(got), "FROM registry.example.com/node:20") {
-			t.Errorf("expected replaced node ref, got %q", string(got))
-		}
-	})
+	d := DockerfileBuildContext{Root: root, HivePrefix: "localhost:5123/"}
+	mounts, err := d.ToLocalMounts()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dockerfileFs := mounts["dockerfile"]
 
-	t.Run("no-op when no __hive__/ references", func(t *testing.T) {
-		dir := t.TempDir()
-		df := filepath.Join(dir, "Dockerfile")
-		content := "FROM ubuntu:22.04\nRUN echo hello"
-		os.WriteFile(df, []byte(content), 0644)
+	expected := "FROM localhost:5123/ubuntu:22.04\nRUN echo hello\n"
+	r, err := dockerfileFs.Open("Dockerfile")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
 
-		err := RewriteHiveRefs(df, df, "localhost:5123")
+	sizes := map[string]int64{}
+	err = dockerfileFs.Walk(t.Context(), "", func(p string, entry fs.DirEntry, err error) error {
 		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
+			return err
 		}
-
-		got, _ := os.ReadFile(df)
-		if string(got) != content {
-			t.Errorf("expected unchanged content, got %q", string(got))
+		info, err := entry.Info()
+		if err != nil {
+			return err
 		}
+		sizes[p] = info.Size()
+		return nil
 	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sizes["Dockerfile"] != int64(len(expected)) {
+		t.Errorf("expected size %d of rewritten Dockerfile, got %d", len(expected), sizes["Dockerfile"])
+	}
+	if _, ok := sizes["Dockerfile.dockerignore"]; !ok {
+		t.Error("expected other files to be served from disk")
+	}
+
+	onDisk, err := os.ReadFile(filepath.Join(root, "Dockerfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(onDisk) != content {
+		t.Errorf("expected Dockerfile on disk to be untouched, got %q", onDisk)
+	}
 }
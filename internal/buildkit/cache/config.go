@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/timo-reymann/ContainerHive/internal/secrets"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+// defaultKey gives every tag and variant its own cache entry
+const defaultKey = "{{ .ImageName }}-{{ .Tag }}"
+
+// invalidKeyChars are replaced in keys used as image tags or directory names.
+var invalidKeyChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// maxKeyLength is the maximum length of an image tag.
+const maxKeyLength = 128
+
+// sanitizeKey turns a rendered cache key into a valid image tag, which is also safe as directory name.
+// Keys exceeding the tag length limit are truncated and suffixed with a hash of the full key to stay unique.
+func sanitizeKey(key string) string {
+	sanitized := strings.TrimLeft(invalidKeyChars.ReplaceAllString(key, "-"), ".-")
+	if len(sanitized) > maxKeyLength {
+		sum := sha256.Sum256([]byte(key))
+		suffix := "-" + hex.EncodeToString(sum[:])[:12]
+		sanitized = sanitized[:maxKeyLength-len(suffix)] + suffix
+	}
+	return sanitized
+}
+
+func resolveOptionalSecret(name string, secret *model.Secret) (string, error) {
+	if secret == nil {
+		return "", nil
+	}
+	resolved, err := secrets.Resolve(secret.SourceType, secret.Value)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", name, err)
+	}
+	return resolved, nil
+}
+
+// KeyContext is available in the cache key template.
+type KeyContext struct {
+	ImageName string
+	// Tag is the tag including the variant suffix
+	Tag string
+	// Variant is the name of the variant, empty for tags
+	Variant string
+}
+
+// Resolver creates the cache of each tag and variant from the project cache configuration.
+type Resolver struct {
+	config  *model.CacheConfig
+	key     *template.Template
+	rootDir string
+	// publishRegistry is where inline caches are imported from
+	publishRegistry string
+	accessKeyId     string
+	secretAccessKey string
+	Mode            Mode
+}
+
+// FromConfig validates the cache configuration of the project and resolves its secrets.
+// It returns nil if no cache is configured.
+func FromConfig(project *model.ContainerHiveProject) (*Resolver, error) {
+	config := project.Config.Cache
+	if config == nil {
+		return nil, nil
+	}
+
+	r := &Resolver{
+		config:          config,
+		rootDir:         project.RootDir,
+		publishRegistry: project.Config.Registries.Publish,
+	}
+
+	mode, err := ParseMode(config.Mode)
+	if err != nil {
+		return nil, err
+	}
+	r.Mode = mode
+
+	key := config.Key
+	if key == "" {
+		key = defaultKey
+	}
+	if r.key, err = template.New("cache key").Option("missingkey=error").Parse(key); err != nil {
+		return nil, errors.Join(errors.New("invalid cache key template"), err)
+	}
+
+	switch config.Type {
+	case "s3":
+		if config.S3 == nil {
+			return nil, fmt.Errorf("cache type %q requires s3 configuration", config.Type)
+		}
+		if r.accessKeyId, err = resolveOptionalSecret("access_key_id", config.S3.AccessKeyId); err != nil {
+			return nil, err
+		}
+		if r.secretAccessKey, err = resolveOptionalSecret("secret_access_key", config.S3.SecretAccessKey); err != nil {
+			return nil, err
+		}
+	case "registry":
+		if config.Registry == nil {
+			return nil, fmt.Errorf("cache type %q requires registry configuration", config.Type)
+		}
+		if _, err := name.NewRepository(config.Registry.Ref); err != nil {
+			return nil, errors.Join(fmt.Errorf("registry ref %q must be a repository without tag or digest, the cache key is appended as tag", config.Registry.Ref), err)
+		}
+	case "local":
+		if config.Local == nil || config.Local.Dir == "" {
+			return nil, fmt.Errorf("cache type %q requires local configuration with dir", config.Type)
+		}
+	case "inline":
+	default:
+		return nil, fmt.Errorf("unsupported cache type %q", config.Type)
+	}
+	return r, nil
+}
+
+// Name returns the type of the configured cache backend.
+func (r *Resolver) Name() string {
+	return r.config.Type
+}
+
+// ForTarget returns the cache of a tag or variant.
+func (r *Resolver) ForTarget(ctx KeyContext) (BuildkitCache, error) {
+	var buf bytes.Buffer
+	if err := r.key.Execute(&buf, ctx); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to render cache key for %s:%s", ctx.ImageName, ctx.Tag), err)
+	}
+	key := buf.String()
+	if r.config.Type == "registry" || r.config.Type == "local" {
+		key = sanitizeKey(key)
+	}
+	if key == "" {
+		return nil, fmt.Errorf("cache key for %s:%s is empty", ctx.ImageName, ctx.Tag)
+	}
+
+	switch r.config.Type {
+	case "s3":
+		return &S3BuildKitCache{
+			EndpointUrl:     r.config.S3.EndpointUrl,
+			Bucket:          r.config.S3.Bucket,
+			Region:          r.config.S3.Region,
+			AccessKeyId:     r.accessKeyId,
+			SecretAccessKey: r.secretAccessKey,
+			UsePathStyle:    r.config.S3.UsePathStyle,
+			CacheKey:        key,
+		}, nil
+	case "registry":
+		return RegistryCache{
+			CacheRef: r.config.Registry.Ref + ":" + key,
+			Insecure: r.config.Registry.Insecure,
+		}, nil
+	case "local":
+		dir := r.config.Local.Dir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(r.rootDir, dir)
+		}
+		return LocalCache{Dir: filepath.Join(dir, key)}, nil
+	default:
+		inline := InlineCache{}
+		if r.publishRegistry != "" {
+			inline.ImportRef = r.publishRegistry + "/" + ctx.ImageName + ":" + ctx.Tag
+		}
+		return inline, nil
+	}
+}
@@ -0,0 +1,266 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+func TestFromConfig(t *testing.T) {
+	t.Setenv("TEST_S3_ACCESS_KEY", "access-key")
+
+	python := KeyContext{ImageName: "python", Tag: "3.13"}
+	variant := KeyContext{ImageName: "dotnet/8", Tag: "8.0-alpine", Variant: "alpine"}
+
+	tests := map[string]struct {
+		config   *model.CacheConfig
+		target   KeyContext
+		expected BuildkitCache
+		mode     Mode
+		wantErr  bool
+	}{
+		"s3 with secrets and default key": {
+			config: &model.CacheConfig{
+				Type: "s3",
+				S3: &model.S3CacheConfig{
+					EndpointUrl:     "http://localhost:9000",
+					Bucket:          "cache",
+					Region:          "us-east-1",
+					AccessKeyId:     &model.Secret{SourceType: "env", Value: "${TEST_S3_ACCESS_KEY}"},
+					SecretAccessKey: &model.Secret{SourceType: "plain", Value: "secret"},
+					UsePathStyle:    true,
+				},
+			},
+			target: python,
+			expected: &S3BuildKitCache{
+				EndpointUrl:     "http://localhost:9000",
+				Bucket:          "cache",
+				Region:          "us-east-1",
+				AccessKeyId:     "access-key",
+				SecretAccessKey: "secret",
+				UsePathStyle:    true,
+				CacheKey:        "python-3.13",
+			},
+			mode: ModeReadWrite,
+		},
+		"s3 with static key": {
+			config: &model.CacheConfig{
+				Type: "s3",
+				Key:  "my-project",
+				S3:   &model.S3CacheConfig{Bucket: "cache"},
+			},
+			target: python,
+			expected: &S3BuildKitCache{
+				Bucket:   "cache",
+				CacheKey: "my-project",
+			},
+			mode: ModeReadWrite,
+		},
+		"s3 with templated key": {
+			config: &model.CacheConfig{
+				Type: "s3",
+				Key:  "my-project/{{ .ImageName }}/{{ .Variant }}",
+				S3:   &model.S3CacheConfig{Bucket: "cache"},
+			},
+			target: variant,
+			expected: &S3BuildKitCache{
+				Bucket:   "cache",
+				CacheKey: "my-project/dotnet/8/alpine",
+			},
+			mode: ModeReadWrite,
+		},
+		"s3 without s3 block": {
+			config:  &model.CacheConfig{Type: "s3"},
+			wantErr: true,
+		},
+		"s3 with unresolvable secret": {
+			config: &model.CacheConfig{
+				Type: "s3",
+				S3: &model.S3CacheConfig{
+					Bucket:      "cache",
+					AccessKeyId: &model.Secret{SourceType: "unknown", Value: "foo"},
+				},
+			},
+			wantErr: true,
+		},
+		"registry": {
+			config: &model.CacheConfig{
+				Type:     "registry",
+				Mode:     "read-only",
+				Registry: &model.RegistryCacheConfig{Ref: "localhost:5000/cache", Insecure: true},
+			},
+			target:   variant,
+			expected: RegistryCache{CacheRef: "localhost:5000/cache:dotnet-8-8.0-alpine", Insecure: true},
+			mode:     ModeReadOnly,
+		},
+		"registry ref with tag": {
+			config: &model.CacheConfig{
+				Type:     "registry",
+				Registry: &model.RegistryCacheConfig{Ref: "localhost:5000/cache:latest"},
+			},
+			wantErr: true,
+		},
+		"registry ref with digest": {
+			config: &model.CacheConfig{
+				Type:     "registry",
+				Registry: &model.RegistryCacheConfig{Ref: "localhost:5000/cache@sha256:" + strings.Repeat("a", 64)},
+			},
+			wantErr: true,
+		},
+		"registry key with leading separators": {
+			config: &model.CacheConfig{
+				Type:     "registry",
+				Key:      "..{{ .ImageName }}",
+				Registry: &model.RegistryCacheConfig{Ref: "localhost:5000/cache"},
+			},
+			target:   python,
+			expected: RegistryCache{CacheRef: "localhost:5000/cache:python"},
+			mode:     ModeReadWrite,
+		},
+		"registry key empty after sanitizing": {
+			config: &model.CacheConfig{
+				Type:     "registry",
+				Key:      "-.-",
+				Registry: &model.RegistryCacheConfig{Ref: "localhost:5000/cache"},
+			},
+			target:  python,
+			wantErr: true,
+		},
+		"registry without registry block": {
+			config:  &model.CacheConfig{Type: "registry"},
+			wantErr: true,
+		},
+		"local relative to project": {
+			config: &model.CacheConfig{
+				Type:  "local",
+				Mode:  "write-only",
+				Local: &model.LocalCacheConfig{Dir: ".cache/buildkit"},
+			},
+			target:   variant,
+			expected: LocalCache{Dir: "/project/.cache/buildkit/dotnet-8-8.0-alpine"},
+			mode:     ModeWriteOnly,
+		},
+		"local with absolute dir": {
+			config: &model.CacheConfig{
+				Type:  "local",
+				Key:   "shared",
+				Local: &model.LocalCacheConfig{Dir: "/var/cache/buildkit"},
+			},
+			target:   python,
+			expected: LocalCache{Dir: "/var/cache/buildkit/shared"},
+			mode:     ModeReadWrite,
+		},
+		"local key cannot escape dir": {
+			config: &model.CacheConfig{
+				Type:  "local",
+				Key:   "../../{{ .ImageName }}",
+				Local: &model.LocalCacheConfig{Dir: "/var/cache/buildkit"},
+			},
+			target:   python,
+			expected: LocalCache{Dir: "/var/cache/buildkit/python"},
+			mode:     ModeReadWrite,
+		},
+		"local without dir": {
+			config:  &model.CacheConfig{Type: "local", Local: &model.LocalCacheConfig{}},
+			wantErr: true,
+		},
+		"inline": {
+			config:   &model.CacheConfig{Type: "inline"},
+			target:   variant,
+			expected: InlineCache{ImportRef: "registry.example.com/library/dotnet/8:8.0-alpine"},
+			mode:     ModeReadWrite,
+		},
+		"invalid key template": {
+			config:  &model.CacheConfig{Type: "inline", Key: "{{ .ImageName"},
+			wantErr: true,
+		},
+		"unknown key field": {
+			config:  &model.CacheConfig{Type: "inline", Key: "{{ .Branch }}"},
+			target:  python,
+			wantErr: true,
+		},
+		"invalid mode": {
+			config:  &model.CacheConfig{Type: "inline", Mode: "append"},
+			wantErr: true,
+		},
+		"unknown type": {
+			config:  &model.CacheConfig{Type: "gha"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			project := &model.ContainerHiveProject{
+				RootDir: "/project",
+				Config: &model.HiveProjectConfig{
+					Cache:      tc.config,
+					Registries: model.RegistriesConfig{Publish: "registry.example.com/library"},
+				},
+			}
+			resolver, err := FromConfig(project)
+			var got BuildkitCache
+			if err == nil {
+				got, err = resolver.ForTarget(tc.target)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("FromConfig() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("ForTarget() mismatch (-expected +got):\n%s", diff)
+			}
+			if resolver.Mode != tc.mode {
+				t.Errorf("expected mode %s, got %s", tc.mode, resolver.Mode)
+			}
+			if resolver.Name() != tc.config.Type {
+				t.Errorf("expected name %s, got %s", tc.config.Type, resolver.Name())
+			}
+		})
+	}
+
+	t.Run("no cache configured", func(t *testing.T) {
+		resolver, err := FromConfig(&model.ContainerHiveProject{Config: &model.HiveProjectConfig{}})
+		if err != nil || resolver != nil {
+			t.Errorf("expected no resolver, got %v (%v)", resolver, err)
+		}
+	})
+}
+
+func TestSanitizeKey(t *testing.T) {
+	long := strings.Repeat("a", 200)
+	longOther := strings.Repeat("a", 199) + "b"
+
+	tests := map[string]struct {
+		key      string
+		expected string
+	}{
+		"valid":           {key: "python-3.13", expected: "python-3.13"},
+		"invalid chars":   {key: "dotnet/8:8.0", expected: "dotnet-8-8.0"},
+		"leading dot":     {key: ".python", expected: "python"},
+		"leading dash":    {key: "/python", expected: "python"},
+		"exactly max":     {key: strings.Repeat("a", maxKeyLength), expected: strings.Repeat("a", maxKeyLength)},
+		"only separators": {key: "./-", expected: ""},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := sanitizeKey(tc.key); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+
+	t.Run("too long", func(t *testing.T) {
+		got := sanitizeKey(long)
+		if len(got) != maxKeyLength {
+			t.Errorf("expected key of length %d, got %d", maxKeyLength, len(got))
+		}
+		if other := sanitizeKey(longOther); other == got {
+			t.Errorf("expected distinct keys after truncation, both are %q", got)
+		}
+	})
+}
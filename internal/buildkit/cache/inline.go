@@ -0,0 +1,22 @@
+package cache
+
+// InlineCache embeds the cache metadata into the exported image. It only covers the layers of the final stage,
+// and is imported from the image previously pushed to ImportRef, if set.
+type InlineCache struct {
+	ImportRef string
+}
+
+func (i InlineCache) Name() string {
+	return "inline"
+}
+
+func (i InlineCache) ToAttributes() map[string]string {
+	return map[string]string{}
+}
+
+func (i InlineCache) ImportEntry() (string, map[string]string) {
+	if i.ImportRef == "" {
+		return "", nil
+	}
+	return "registry", map[string]string{"ref": i.ImportRef}
+}
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInlineCache(t *testing.T) {
+	cache := InlineCache{ImportRef: "registry.example.com/python:3.13"}
+
+	if cache.Name() != "inline" {
+		t.Errorf("Name() = %q, want %q", cache.Name(), "inline")
+	}
+	if len(cache.ToAttributes()) != 0 {
+		t.Errorf("expected no attributes, got %v", cache.ToAttributes())
+	}
+
+	typ, attrs := cache.ImportEntry()
+	if typ != "registry" {
+		t.Errorf("expected import type registry, got %q", typ)
+	}
+	if diff := cmp.Diff(map[string]string{"ref": "registry.example.com/python:3.13"}, attrs); diff != "" {
+		t.Errorf("ImportEntry() mismatch (-expected +got):\n%s", diff)
+	}
+
+	t.Run("nothing to import without ref", func(t *testing.T) {
+		if typ, _ := (InlineCache{}).ImportEntry(); typ != "" {
+			t.Errorf("expected no import, got %q", typ)
+		}
+	})
+}
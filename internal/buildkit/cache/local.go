@@ -0,0 +1,21 @@
+package cache
+
+// LocalCache stores the cache in a directory on the machine running the build.
+type LocalCache struct {
+	Dir string
+}
+
+func (l LocalCache) Name() string {
+	return "local"
+}
+
+func (l LocalCache) ToAttributes() map[string]string {
+	return map[string]string{
+		"dest": l.Dir,
+		"mode": "max",
+	}
+}
+
+func (l LocalCache) ImportEntry() (string, map[string]string) {
+	return "local", map[string]string{"src": l.Dir}
+}
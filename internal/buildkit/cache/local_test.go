@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLocalCache(t *testing.T) {
+	cache := LocalCache{Dir: "/var/cache/buildkit/python-3.13"}
+
+	if cache.Name() != "local" {
+		t.Errorf("Name() = %q, want %q", cache.Name(), "local")
+	}
+	if diff := cmp.Diff(map[string]string{"dest": "/var/cache/buildkit/python-3.13", "mode": "max"}, cache.ToAttributes()); diff != "" {
+		t.Errorf("ToAttributes() mismatch (-expected +got):\n%s", diff)
+	}
+
+	typ, attrs := cache.ImportEntry()
+	if typ != "local" {
+		t.Errorf("expected import type local, got %q", typ)
+	}
+	if diff := cmp.Diff(map[string]string{"src": "/var/cache/buildkit/python-3.13"}, attrs); diff != "" {
+		t.Errorf("ImportEntry() mismatch (-expected +got):\n%s", diff)
+	}
+}
@@ -1,6 +1,50 @@
 package cache
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
 type BuildkitCache interface {
 	Name() string
 	ToAttributes() map[string]string
 }
+
+// ImportSource is implemented by caches that are imported from another backend or with other attributes than
+// they are exported with. An empty type means there is nothing to import.
+type ImportSource interface {
+	ImportEntry() (string, map[string]string)
+}
+
+// Mode controls whether the cache is imported before and exported after builds.
+type Mode string
+
+const (
+	ModeReadWrite Mode = "read-write"
+	ModeReadOnly  Mode = "read-only"
+	ModeWriteOnly Mode = "write-only"
+)
+
+var Modes = []string{string(ModeReadWrite), string(ModeReadOnly), string(ModeWriteOnly)}
+
+// ParseMode validates the name of a cache mode, an empty name is read-write.
+func ParseMode(name string) (Mode, error) {
+	if name == "" {
+		return ModeReadWrite, nil
+	}
+	if !slices.Contains(Modes, name) {
+		return "", fmt.Errorf("unsupported cache mode %q, expected one of %s", name, strings.Join(Modes, ", "))
+	}
+	return Mode(name), nil
+}
+
+// Imports reports whether the cache is imported before builds.
+func (m Mode) Imports() bool {
+	return m != ModeWriteOnly
+}
+
+// Exports reports whether the cache is exported after builds.
+func (m Mode) Exports() bool {
+	return m != ModeReadOnly
+}
@@ -0,0 +1,34 @@
+package cache
+
+import "testing"
+
+func TestParseMode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected Mode
+		imports  bool
+		exports  bool
+	}{
+		{name: "", expected: ModeReadWrite, imports: true, exports: true},
+		{name: "read-write", expected: ModeReadWrite, imports: true, exports: true},
+		{name: "read-only", expected: ModeReadOnly, imports: true},
+		{name: "write-only", expected: ModeWriteOnly, exports: true},
+	}
+
+	for _, tc := range testCases {
+		mode, err := ParseMode(tc.name)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tc.name, err)
+		}
+		if mode != tc.expected {
+			t.Errorf("ParseMode(%q) = %s, expected %s", tc.name, mode, tc.expected)
+		}
+		if mode.Imports() != tc.imports || mode.Exports() != tc.exports {
+			t.Errorf("mode %s: imports=%v exports=%v, expected imports=%v exports=%v", mode, mode.Imports(), mode.Exports(), tc.imports, tc.exports)
+		}
+	}
+
+	if _, err := ParseMode("off"); err == nil {
+		t.Error("expected error for unsupported mode")
+	}
+}
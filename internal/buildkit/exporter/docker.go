@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"io"
+
+	"github.com/moby/buildkit/client"
+)
+
+// DockerLoad streams the image as docker image tar into Load, e.g. to load it into the local docker daemon.
+type DockerLoad struct {
+	Load func(r io.Reader) error
+}
+
+func (d DockerLoad) ToExportEntry(attrs map[string]string) client.ExportEntry {
+	return client.ExportEntry{
+		Type:  client.ExporterDocker,
+		Attrs: attrs,
+		Output: func(_ map[string]string) (io.WriteCloser, error) {
+			reader, writer := io.Pipe()
+			done := make(chan error, 1)
+			go func() {
+				err := d.Load(reader)
+				// Unblock the exporter if loading stopped before the whole image was read
+				_ = reader.CloseWithError(err)
+				done <- err
+			}()
+			return &loadWriter{PipeWriter: writer, done: done}, nil
+		},
+	}
+}
+
+// loadWriter waits for the image to be loaded when the exporter closes the stream.
+type loadWriter struct {
+	*io.PipeWriter
+	done chan error
+}
+
+func (w *loadWriter) Close() error {
+	if err := w.PipeWriter.Close(); err != nil {
+		return err
+	}
+	return <-w.done
+}
@@ -0,0 +1,56 @@
+package exporter
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/moby/buildkit/client"
+)
+
+func TestDockerLoad(t *testing.T) {
+	t.Run("waits for the image to be loaded", func(t *testing.T) {
+		var loaded []byte
+		entry := DockerLoad{Load: func(r io.Reader) error {
+			var err error
+			loaded, err = io.ReadAll(r)
+			return err
+		}}.ToExportEntry(map[string]string{"name": "python:3.13"})
+
+		if entry.Type != client.ExporterDocker {
+			t.Errorf("expected type %q, got %q", client.ExporterDocker, entry.Type)
+		}
+
+		out, err := entry.Output(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := out.Write([]byte("image")); err != nil {
+			t.Fatal(err)
+		}
+		if err := out.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(loaded) != "image" {
+			t.Errorf("expected image to be loaded, got %q", loaded)
+		}
+	})
+
+	t.Run("returns load error", func(t *testing.T) {
+		loadErr := errors.New("daemon unavailable")
+		entry := DockerLoad{Load: func(r io.Reader) error {
+			return loadErr
+		}}.ToExportEntry(nil)
+
+		out, err := entry.Output(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := out.Write([]byte("image")); !errors.Is(err, loadErr) {
+			t.Errorf("expected write to fail with load error, got %v", err)
+		}
+		if err := out.Close(); !errors.Is(err, loadErr) {
+			t.Errorf("expected close to return load error, got %v", err)
+		}
+	})
+}
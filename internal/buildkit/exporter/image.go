@@ -0,0 +1,29 @@
+package exporter
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/moby/buildkit/client"
+)
+
+// RegistryPush pushes the image from buildkit straight to a registry.
+type RegistryPush struct {
+	// Refs are the references to push the image as, e.g. registry.example.com/library/python:3.13
+	Refs     []string
+	Insecure bool
+}
+
+func (r RegistryPush) ToExportEntry(attrs map[string]string) client.ExportEntry {
+	pushAttrs := maps.Clone(attrs)
+	pushAttrs["name"] = strings.Join(r.Refs, ",")
+	pushAttrs["push"] = "true"
+	pushAttrs["oci-mediatypes"] = "true"
+	if r.Insecure {
+		pushAttrs["registry.insecure"] = "true"
+	}
+	return client.ExportEntry{
+		Type:  client.ExporterImage,
+		Attrs: pushAttrs,
+	}
+}
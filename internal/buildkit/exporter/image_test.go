@@ -0,0 +1,50 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/moby/buildkit/client"
+)
+
+func TestRegistryPush(t *testing.T) {
+	testCases := []struct {
+		name     string
+		push     RegistryPush
+		expected map[string]string
+	}{
+		{
+			name: "multiple refs",
+			push: RegistryPush{Refs: []string{"registry.example.com/python:3.13.7", "registry.example.com/python:3.13"}},
+			expected: map[string]string{
+				"name":              "registry.example.com/python:3.13.7,registry.example.com/python:3.13",
+				"push":              "true",
+				"oci-mediatypes":    "true",
+				"rewrite-timestamp": "true",
+			},
+		},
+		{
+			name: "insecure registry",
+			push: RegistryPush{Refs: []string{"127.0.0.1:5000/python:3.13"}, Insecure: true},
+			expected: map[string]string{
+				"name":              "127.0.0.1:5000/python:3.13",
+				"push":              "true",
+				"oci-mediatypes":    "true",
+				"registry.insecure": "true",
+				"rewrite-timestamp": "true",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entry := tc.push.ToExportEntry(map[string]string{"name": "python:3.13", "rewrite-timestamp": "true"})
+			if entry.Type != client.ExporterImage {
+				t.Errorf("expected type %q, got %q", client.ExporterImage, entry.Type)
+			}
+			if diff := cmp.Diff(tc.expected, entry.Attrs); diff != "" {
+				t.Errorf("attributes mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
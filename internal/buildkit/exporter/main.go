@@ -0,0 +1,10 @@
+package exporter
+
+import "github.com/moby/buildkit/client"
+
+// BuildkitExporter is a destination buildkit exports the built image to.
+type BuildkitExporter interface {
+	// ToExportEntry returns the buildkit export entry. The attributes are shared by all exporters of a build, e.g.
+	// the image name and annotations, exporter specific attributes take precedence.
+	ToExportEntry(attrs map[string]string) client.ExportEntry
+}
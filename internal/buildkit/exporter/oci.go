@@ -0,0 +1,39 @@
+package exporter
+
+import (
+	"io"
+	"maps"
+	"os"
+
+	"github.com/moby/buildkit/client"
+)
+
+// OCITar writes the image as OCI layout tar file.
+type OCITar struct {
+	Path string
+}
+
+func (o OCITar) ToExportEntry(attrs map[string]string) client.ExportEntry {
+	return client.ExportEntry{
+		Type:  client.ExporterOCI,
+		Attrs: attrs,
+		Output: func(_ map[string]string) (io.WriteCloser, error) {
+			return os.Create(o.Path)
+		},
+	}
+}
+
+// OCILayout writes the image into an unpacked OCI layout directory.
+type OCILayout struct {
+	Dir string
+}
+
+func (o OCILayout) ToExportEntry(attrs map[string]string) client.ExportEntry {
+	layoutAttrs := maps.Clone(attrs)
+	layoutAttrs["tar"] = "false"
+	return client.ExportEntry{
+		Type:      client.ExporterOCI,
+		Attrs:     layoutAttrs,
+		OutputDir: o.Dir,
+	}
+}
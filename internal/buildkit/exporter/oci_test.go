@@ -0,0 +1,48 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/moby/buildkit/client"
+)
+
+func TestOCITar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.tar")
+	entry := OCITar{Path: path}.ToExportEntry(map[string]string{"name": "python:3.13"})
+
+	if entry.Type != client.ExporterOCI {
+		t.Errorf("expected type %q, got %q", client.ExporterOCI, entry.Type)
+	}
+	if diff := cmp.Diff(map[string]string{"name": "python:3.13"}, entry.Attrs); diff != "" {
+		t.Errorf("attributes mismatch (-expected +got):\n%s", diff)
+	}
+
+	out, err := entry.Output(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected tar file to be created: %v", err)
+	}
+}
+
+func TestOCILayout(t *testing.T) {
+	attrs := map[string]string{"name": "python:3.13"}
+	entry := OCILayout{Dir: "/dist/python/3.13/oci-layout"}.ToExportEntry(attrs)
+
+	if entry.Type != client.ExporterOCI || entry.OutputDir != "/dist/python/3.13/oci-layout" || entry.Output != nil {
+		t.Errorf("unexpected export entry %+v", entry)
+	}
+	if diff := cmp.Diff(map[string]string{"name": "python:3.13", "tar": "false"}, entry.Attrs); diff != "" {
+		t.Errorf("attributes mismatch (-expected +got):\n%s", diff)
+	}
+	if _, ok := attrs["tar"]; ok {
+		t.Error("expected shared attributes not to be modified")
+	}
+}
@@ -3,17 +3,21 @@ package buildkit
 import (
 	"context"
 	"errors"
-	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/docker/cli/cli/config"
 	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/session/auth/authprovider"
 	"github.com/moby/buildkit/session/secrets/secretsprovider"
 	"github.com/timo-reymann/ContainerHive/internal/buildkit/build_context"
 	"github.com/timo-reymann/ContainerHive/internal/buildkit/cache"
+	"github.com/timo-reymann/ContainerHive/internal/buildkit/exporter"
 	"github.com/timo-reymann/ContainerHive/internal/utils"
 	"golang.org/x/sync/errgroup"
 )
@@ -24,13 +28,20 @@ type Client struct {
 
 type BuildOpts struct {
 	ImageName    string
-	Platform     string
-	TarFile      string
+	Platforms    []string
+	Exporters    []exporter.BuildkitExporter
 	BuildArgs    map[string]string
 	Secrets      map[string][]byte
 	Labels       map[string]string
+	Annotations  map[string]string
 	Cache        cache.BuildkitCache
 	BuildContext build_context.BuildContext
+	// CacheMode controls whether the cache is imported and exported, the zero value does both
+	CacheMode cache.Mode
+	// OCILayoutContexts replaces images referenced in the Dockerfile with local OCI layouts instead of pulling them
+	OCILayoutContexts map[string]OCILayoutContext
+	// SourceDateEpoch is passed as SOURCE_DATE_EPOCH build arg and used to rewrite the timestamps of the image
+	SourceDateEpoch int64
 }
 
 func NewClient(ctx context.Context, endpoint string) (*Client, error) {
@@ -53,32 +64,19 @@ func (c *Client) Version(ctx context.Context) (string, error) {
 	return info.BuildkitVersion.Version, nil
 }
 
-func (c *Client) Build(ctx context.Context, opts *BuildOpts, statusUpdateHandler func(chan *client.SolveStatus) error) error {
-	var buildCache []client.CacheOptionsEntry
-	if opts.Cache != nil {
-		cacheOpt := opts.Cache
-		attributes := cacheOpt.ToAttributes()
-		_, hasExplicitIgnoreErr := attributes["ignore-errors"]
-		if !hasExplicitIgnoreErr {
-			attributes["ignore-errors"] = "true"
-		}
-		buildCache = []client.CacheOptionsEntry{
-			{
-				Type:  cacheOpt.Name(),
-				Attrs: attributes,
-			},
-		}
-	}
+// Build builds the image and exports it with all exporters. It returns the digest of the exported image.
+func (c *Client) Build(ctx context.Context, opts *BuildOpts, statusUpdateHandler func(chan *client.SolveStatus) error) (string, error) {
+	cacheImports, cacheExports := cacheEntries(opts.Cache, opts.CacheMode)
 
 	localMounts, err := opts.BuildContext.ToLocalMounts()
 	if err != nil {
-		return errors.Join(errors.New("failed to mount build context"), err)
+		return "", errors.Join(errors.New("failed to mount build context"), err)
 	}
 
 	frontendAttrs := map[string]string{
 		"filename":                    filepath.Base(opts.BuildContext.FileName()),
-		"build-arg:SOURCE_DATE_EPOCH": "1770336000",
-		"platform":                    opts.Platform,
+		"build-arg:SOURCE_DATE_EPOCH": strconv.FormatInt(opts.SourceDateEpoch, 10),
+		"platform":                    strings.Join(opts.Platforms, ","),
 		// this will be done using syft explicitly
 		// as this should not rely on a upstream image
 		// "attest:sbom":                 "",
@@ -87,6 +85,22 @@ func (c *Client) Build(ctx context.Context, opts *BuildOpts, statusUpdateHandler
 	utils.MergeMapWithPrefix("label:", frontendAttrs, opts.Labels)
 	utils.MergeMapWithPrefix("build-arg:", frontendAttrs, opts.BuildArgs)
 
+	contextAttrs, ociStores, err := namedContexts(opts.OCILayoutContexts)
+	if err != nil {
+		return "", err
+	}
+	maps.Copy(frontendAttrs, contextAttrs)
+
+	exportAttrs := map[string]string{
+		"name":              opts.ImageName,
+		"rewrite-timestamp": "true",
+	}
+	maps.Copy(exportAttrs, annotationAttrs(opts.Annotations, len(opts.Platforms) > 1))
+	exports := make([]client.ExportEntry, 0, len(opts.Exporters))
+	for _, e := range opts.Exporters {
+		exports = append(exports, e.ToExportEntry(maps.Clone(exportAttrs)))
+	}
+
 	dockerConfig := config.LoadDefaultConfigFile(os.Stderr)
 	solveOpts := client.SolveOpt{
 		Session: []session.Attachable{
@@ -95,23 +109,13 @@ func (c *Client) Build(ctx context.Context, opts *BuildOpts, statusUpdateHandler
 			}),
 			secretsprovider.FromMap(opts.Secrets),
 		},
-		CacheExports: buildCache,
-		CacheImports: buildCache,
-		Exports: []client.ExportEntry{
-			{
-				Type: "oci",
-				Attrs: map[string]string{
-					"name":              opts.ImageName,
-					"rewrite-timestamp": "true",
-				},
-				Output: func(_ map[string]string) (io.WriteCloser, error) {
-					return os.Create(opts.TarFile)
-				},
-			},
-		},
+		CacheExports:  cacheExports,
+		CacheImports:  cacheImports,
+		Exports:       exports,
 		LocalMounts:   localMounts,
 		Frontend:      opts.BuildContext.FrontendType(),
 		FrontendAttrs: frontendAttrs,
+		OCIStores:     ociStores,
 	}
 
 	statusUpdates := make(chan *client.SolveStatus)
@@ -120,10 +124,61 @@ func (c *Client) Build(ctx context.Context, opts *BuildOpts, statusUpdateHandler
 		return statusUpdateHandler(statusUpdates)
 	})
 
+	var digest string
 	eg.Go(func() error {
-		_, err = c.buildkit.Build(ctx, solveOpts, "ContainerHive", opts.BuildContext.RunBuild, statusUpdates)
-		return err
+		resp, err := c.buildkit.Build(ctx, solveOpts, "ContainerHive", opts.BuildContext.RunBuild, statusUpdates)
+		if err != nil {
+			return err
+		}
+		digest = resp.ExporterResponse[exptypes.ExporterImageDigestKey]
+		return nil
 	})
 
-	return eg.Wait()
+	if err := eg.Wait(); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// cacheEntries returns the cache imports and exports of the build. Cache export errors do not fail the build
+// unless the cache explicitly sets ignore-errors.
+func cacheEntries(buildCache cache.BuildkitCache, mode cache.Mode) ([]client.CacheOptionsEntry, []client.CacheOptionsEntry) {
+	if buildCache == nil {
+		return nil, nil
+	}
+	attributes := buildCache.ToAttributes()
+	if _, hasExplicitIgnoreErr := attributes["ignore-errors"]; !hasExplicitIgnoreErr {
+		attributes["ignore-errors"] = "true"
+	}
+
+	var imports, exports []client.CacheOptionsEntry
+	if mode.Exports() {
+		exports = []client.CacheOptionsEntry{{Type: buildCache.Name(), Attrs: attributes}}
+	}
+	if mode.Imports() {
+		importType, importAttrs := buildCache.Name(), attributes
+		if source, ok := buildCache.(cache.ImportSource); ok {
+			importType, importAttrs = source.ImportEntry()
+		}
+		if importType != "" {
+			imports = []client.CacheOptionsEntry{{Type: importType, Attrs: importAttrs}}
+		}
+	}
+	return imports, exports
+}
+
+// annotationAttrs returns the exporter attributes setting the annotations on the image manifests and on their
+// descriptors in the OCI layout. Multi-platform images get them on the image index and its descriptor instead.
+func annotationAttrs(annotations map[string]string, multiPlatform bool) map[string]string {
+	attrs := make(map[string]string, len(annotations)*3)
+	for k, v := range annotations {
+		attrs[exptypes.AnnotationManifestKey(nil, k)] = v
+		if multiPlatform {
+			attrs[exptypes.AnnotationIndexKey(k)] = v
+			attrs[exptypes.AnnotationIndexDescriptorKey(k)] = v
+		} else {
+			attrs[exptypes.AnnotationManifestDescriptorKey(nil, k)] = v
+		}
+	}
+	return attrs
 }
@@ -11,12 +11,14 @@ import (
 	"testing"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/google/go-cmp/cmp"
 	"github.com/moby/buildkit/client"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/network"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"github.com/timo-reymann/ContainerHive/internal/buildkit/build_context"
 	"github.com/timo-reymann/ContainerHive/internal/buildkit/cache"
+	"github.com/timo-reymann/ContainerHive/internal/buildkit/exporter"
 	"github.com/timo-reymann/ContainerHive/internal/testutil"
 )
 
@@ -135,13 +137,13 @@ func TestIntegrationBuild(t *testing.T) {
 	t.Run("without_cache", func(t *testing.T) {
 		tarFile := filepath.Join(t.TempDir(), "output.tar")
 
-		err := bkClient.Build(ctx, &BuildOpts{
+		_, err := bkClient.Build(ctx, &BuildOpts{
 			ImageName: "test-no-cache:latest",
-			TarFile:   tarFile,
+			Exporters: []exporter.BuildkitExporter{exporter.OCITar{Path: tarFile}},
 			BuildContext: &build_context.DockerfileBuildContext{
 				Root: buildCtxDir,
 			},
-			Platform: platform,
+			Platforms: []string{platform},
 		}, drainStatus)
 		if err != nil {
 			t.Fatal(err)
@@ -171,14 +173,14 @@ func TestIntegrationBuild(t *testing.T) {
 
 		// First build — populates the cache
 		tarFile1 := filepath.Join(tmpDir, "cached1.tar")
-		if err := bkClient.Build(ctx, &BuildOpts{
+		if _, err := bkClient.Build(ctx, &BuildOpts{
 			ImageName: "test-cached:latest",
-			TarFile:   tarFile1,
+			Exporters: []exporter.BuildkitExporter{exporter.OCITar{Path: tarFile1}},
 			Cache:     s3Cache,
 			BuildContext: &build_context.DockerfileBuildContext{
 				Root: buildCtxDir,
 			},
-			Platform: platform,
+			Platforms: []string{platform},
 		}, drainStatus); err != nil {
 			t.Fatal("first build (cache populate):", err)
 		}
@@ -193,14 +195,14 @@ func TestIntegrationBuild(t *testing.T) {
 
 		// Second build — should use the cache
 		tarFile2 := filepath.Join(tmpDir, "cached2.tar")
-		if err := bkClient.Build(ctx, &BuildOpts{
+		if _, err := bkClient.Build(ctx, &BuildOpts{
 			ImageName: "test-cached-reuse:latest",
-			TarFile:   tarFile2,
+			Exporters: []exporter.BuildkitExporter{exporter.OCITar{Path: tarFile2}},
 			Cache:     s3Cache,
 			BuildContext: &build_context.DockerfileBuildContext{
 				Root: buildCtxDir,
 			},
-			Platform: platform,
+			Platforms: []string{platform},
 		}, drainStatus); err != nil {
 			t.Fatal("second build (cache reuse):", err)
 		}
@@ -252,14 +254,14 @@ func TestIntegrationBuild(t *testing.T) {
 
 		// First build — populates the cache
 		tarFile1 := filepath.Join(t.TempDir(), "registry-cached1.tar")
-		if err := bkClient.Build(ctx, &BuildOpts{
+		if _, err := bkClient.Build(ctx, &BuildOpts{
 			ImageName: "test-registry-cached:latest",
-			TarFile:   tarFile1,
+			Exporters: []exporter.BuildkitExporter{exporter.OCITar{Path: tarFile1}},
 			Cache:     registryCache,
 			BuildContext: &build_context.DockerfileBuildContext{
 				Root: buildCtxDir,
 			},
-			Platform: platform,
+			Platforms: []string{platform},
 		}, drainStatus); err != nil {
 			t.Fatal("first build (cache populate):", err)
 		}
@@ -274,14 +276,14 @@ func TestIntegrationBuild(t *testing.T) {
 
 		// Second build — should use the cache
 		tarFile2 := filepath.Join(t.TempDir(), "registry-cached2.tar")
-		if err := bkClient.Build(ctx, &BuildOpts{
+		if _, err := bkClient.Build(ctx, &BuildOpts{
 			ImageName: "test-registry-cached-reuse:latest",
-			TarFile:   tarFile2,
+			Exporters: []exporter.BuildkitExporter{exporter.OCITar{Path: tarFile2}},
 			Cache:     registryCache,
 			BuildContext: &build_context.DockerfileBuildContext{
 				Root: buildCtxDir,
 			},
-			Platform: platform,
+			Platforms: []string{platform},
 		}, drainStatus); err != nil {
 			t.Fatal("second build (cache reuse):", err)
 		}
@@ -329,14 +331,14 @@ func TestIntegrationBuild(t *testing.T) {
 
 		// Build should succeed even if cache operations might fail
 		tarFile := filepath.Join(t.TempDir(), "ignore-errors.tar")
-		if err := bkClient.Build(ctx, &BuildOpts{
+		if _, err := bkClient.Build(ctx, &BuildOpts{
 			ImageName: "test-ignore-errors:latest",
-			TarFile:   tarFile,
+			Exporters: []exporter.BuildkitExporter{exporter.OCITar{Path: tarFile}},
 			Cache:     s3Cache,
 			BuildContext: &build_context.DockerfileBuildContext{
 				Root: buildCtxDir,
 			},
-			Platform: platform,
+			Platforms: []string{platform},
 		}, drainStatus); err != nil {
 			t.Fatal("build should succeed even with cache issues:", err)
 		}
@@ -389,3 +391,97 @@ func TestIgnoreErrorsHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestCacheEntries(t *testing.T) {
+	s3Attrs := (&cache.S3BuildKitCache{Bucket: "test", CacheKey: "python-3.13"}).ToAttributes()
+	s3Attrs["ignore-errors"] = "true"
+	s3Entry := []client.CacheOptionsEntry{{Type: "s3", Attrs: s3Attrs}}
+
+	tests := []struct {
+		name            string
+		cache           cache.BuildkitCache
+		mode            cache.Mode
+		expectedImports []client.CacheOptionsEntry
+		expectedExports []client.CacheOptionsEntry
+	}{
+		{
+			name: "no cache",
+		},
+		{
+			name:            "read-write by default",
+			cache:           &cache.S3BuildKitCache{Bucket: "test", CacheKey: "python-3.13"},
+			expectedImports: s3Entry,
+			expectedExports: s3Entry,
+		},
+		{
+			name:            "read-only",
+			cache:           &cache.S3BuildKitCache{Bucket: "test", CacheKey: "python-3.13"},
+			mode:            cache.ModeReadOnly,
+			expectedImports: s3Entry,
+		},
+		{
+			name:            "write-only",
+			cache:           &cache.S3BuildKitCache{Bucket: "test", CacheKey: "python-3.13"},
+			mode:            cache.ModeWriteOnly,
+			expectedExports: s3Entry,
+		},
+		{
+			name:            "local imports from src",
+			cache:           cache.LocalCache{Dir: "/cache/python-3.13"},
+			expectedImports: []client.CacheOptionsEntry{{Type: "local", Attrs: map[string]string{"src": "/cache/python-3.13"}}},
+			expectedExports: []client.CacheOptionsEntry{{Type: "local", Attrs: map[string]string{
+				"dest":          "/cache/python-3.13",
+				"mode":          "max",
+				"ignore-errors": "true",
+			}}},
+		},
+		{
+			name:            "inline imports from registry",
+			cache:           cache.InlineCache{ImportRef: "registry.example.com/python:3.13"},
+			expectedImports: []client.CacheOptionsEntry{{Type: "registry", Attrs: map[string]string{"ref": "registry.example.com/python:3.13"}}},
+			expectedExports: []client.CacheOptionsEntry{{Type: "inline", Attrs: map[string]string{"ignore-errors": "true"}}},
+		},
+		{
+			name:            "inline without published image",
+			cache:           cache.InlineCache{},
+			expectedExports: []client.CacheOptionsEntry{{Type: "inline", Attrs: map[string]string{"ignore-errors": "true"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imports, exports := cacheEntries(tt.cache, tt.mode)
+			if diff := cmp.Diff(tt.expectedImports, imports); diff != "" {
+				t.Errorf("imports mismatch (-expected +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedExports, exports); diff != "" {
+				t.Errorf("exports mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAnnotationAttrs(t *testing.T) {
+	annotations := map[string]string{"org.opencontainers.image.version": "3.13.7"}
+
+	t.Run("single platform", func(t *testing.T) {
+		expected := map[string]string{
+			"annotation-manifest.org.opencontainers.image.version":            "3.13.7",
+			"annotation-manifest-descriptor.org.opencontainers.image.version": "3.13.7",
+		}
+		if diff := cmp.Diff(expected, annotationAttrs(annotations, false)); diff != "" {
+			t.Errorf("annotationAttrs() mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("multi platform", func(t *testing.T) {
+		expected := map[string]string{
+			"annotation-manifest.org.opencontainers.image.version":         "3.13.7",
+			"annotation-index.org.opencontainers.image.version":            "3.13.7",
+			"annotation-index-descriptor.org.opencontainers.image.version": "3.13.7",
+		}
+		if diff := cmp.Diff(expected, annotationAttrs(annotations, true)); diff != "" {
+			t.Errorf("annotationAttrs() mismatch (-expected +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package buildkit
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+)
+
+// OCILayoutContext is an image in an unpacked OCI layout directory, e.g. written by the oci-layout exporter.
+type OCILayoutContext struct {
+	Dir string
+	// Digest is the root descriptor digest of the layout
+	Digest string
+}
+
+// namedContexts returns the frontend attributes resolving the image references to their OCI layouts
+// and the content stores serving the layouts to buildkit.
+func namedContexts(contexts map[string]OCILayoutContext) (map[string]string, map[string]content.Store, error) {
+	attrs := make(map[string]string, len(contexts))
+	stores := make(map[string]content.Store, len(contexts))
+	for i, ref := range slices.Sorted(maps.Keys(contexts)) {
+		layout := contexts[ref]
+		store, err := local.NewStore(layout.Dir)
+		if err != nil {
+			return nil, nil, errors.Join(fmt.Errorf("failed to open OCI layout %s", layout.Dir), err)
+		}
+		storeID := fmt.Sprintf("hive-%d", i)
+		stores[storeID] = store
+		attrs["context:"+ref] = "oci-layout://" + storeID + "@" + layout.Digest
+	}
+	return attrs, stores, nil
+}
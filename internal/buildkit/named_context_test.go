@@ -0,0 +1,32 @@
+package buildkit
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNamedContexts(t *testing.T) {
+	contexts := map[string]OCILayoutContext{
+		"containerhive.local/ubuntu:22.04": {Dir: t.TempDir(), Digest: "sha256:aaaa"},
+		"containerhive.local/node":         {Dir: t.TempDir(), Digest: "sha256:bbbb"},
+	}
+
+	attrs, stores, err := namedContexts(contexts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"context:containerhive.local/node":         "oci-layout://hive-0@sha256:bbbb",
+		"context:containerhive.local/ubuntu:22.04": "oci-layout://hive-1@sha256:aaaa",
+	}
+	if diff := cmp.Diff(expected, attrs); diff != "" {
+		t.Errorf("namedContexts() mismatch (-expected +got):\n%s", diff)
+	}
+	for _, storeID := range []string{"hive-0", "hive-1"} {
+		if stores[storeID] == nil {
+			t.Errorf("expected content store %s", storeID)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/timo-reymann/ContainerHive/internal/affected"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+var affectedFormats = []string{"text", "json", "only"}
+
+type affectedOptions struct {
+	Base   string
+	Format string
+}
+
+// affectedResult is the selection computed from a git diff.
+type affectedResult struct {
+	// Images are the identifiers of the images changed directly
+	Images []string `json:"images"`
+	// Targets are all tags and variants to build in build order, including the dependents of the changed images
+	Targets []string `json:"targets"`
+}
+
+func newAffectedCommand(opts *globalOptions) *cobra.Command {
+	affectedOpts := &affectedOptions{}
+	cmd := &cobra.Command{
+		Use:   "affected",
+		Short: "Print the tags and variants affected by the changes since a git ref, including their dependents",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !slices.Contains(affectedFormats, affectedOpts.Format) {
+				return fmt.Errorf("unsupported affected format %q, expected one of %s", affectedOpts.Format, strings.Join(affectedFormats, ", "))
+			}
+			if affectedOpts.Base == "" {
+				return errors.New("no base git ref given, pass --base")
+			}
+
+			project, err := discoverProject(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+
+			changedFiles, err := affected.ChangedFiles(project.RootDir, affectedOpts.Base)
+			if err != nil {
+				return err
+			}
+			images := affected.Images(project, changedFiles)
+
+			distPath, cleanup, err := renderTemporary(cmd.Context(), project)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			graph, err := resolveDependencyGraph(distPath, project)
+			if err != nil {
+				return err
+			}
+
+			result := affectedTargets(graph, collectTargets(project, distPath, imageNames(project)), images)
+			return writeAffected(cmd.OutOrStdout(), result, affectedOpts.Format)
+		},
+	}
+	cmd.Flags().StringVar(&affectedOpts.Base, "base", envOrDefault("AFFECTED_BASE", ""), "Git ref to compare HEAD against, e.g. origin/main [$"+envPrefix+"AFFECTED_BASE]")
+	cmd.Flags().StringVar(&affectedOpts.Format, "format", envOrDefault("AFFECTED_FORMAT", "text"), "Output format, one of "+strings.Join(affectedFormats, ", ")+", only prints a value for --only [$"+envPrefix+"AFFECTED_FORMAT]")
+	return cmd
+}
+
+// affectedTargets returns the tags and variants of the changed images and all their dependents in build order.
+func affectedTargets(graph *projectGraph, targets []*buildTarget, images []*model.Image) *affectedResult {
+	result := &affectedResult{Images: []string{}, Targets: []string{}}
+	var changed []string
+	for _, image := range images {
+		result.Images = append(result.Images, image.Identifier)
+		for _, target := range targets {
+			if target.Image == image {
+				changed = append(changed, target.ImageTag())
+			}
+		}
+	}
+
+	selected := append(changed, graph.Graph.TransitiveDependents(changed...)...)
+	for _, key := range graph.BuildOrder {
+		if slices.Contains(selected, key) {
+			result.Targets = append(result.Targets, key)
+		}
+	}
+	return result
+}
+
+func writeAffected(out io.Writer, result *affectedResult, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	case "only":
+		_, err := fmt.Fprintln(out, strings.Join(result.Targets, ","))
+		return err
+	default:
+		for _, target := range result.Targets {
+			if _, err := fmt.Fprintln(out, target); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
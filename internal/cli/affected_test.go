@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+func TestAffectedTargets(t *testing.T) {
+	targets, graph := selectionFixture()
+	project := &projectGraph{
+		Graph:      graph,
+		BuildOrder: []string{"ubuntu:22.04", "python:3.12", "python:3.13", "python:3.13-node", "app:1.0"},
+	}
+	imageOf := func(name string) *model.Image {
+		for _, target := range targets {
+			if target.Image.Name == name {
+				return target.Image
+			}
+		}
+		t.Fatalf("unknown image %s", name)
+		return nil
+	}
+
+	testCases := []struct {
+		name     string
+		images   []*model.Image
+		expected []string
+	}{
+		{name: "nothing changed", expected: []string{}},
+		{name: "leaf", images: []*model.Image{imageOf("app")}, expected: []string{"app:1.0"}},
+		{name: "dependents are added", images: []*model.Image{imageOf("python")}, expected: []string{"python:3.12", "python:3.13", "python:3.13-node", "app:1.0"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := affectedTargets(project, targets, tc.images)
+			if diff := cmp.Diff(tc.expected, result.Targets); diff != "" {
+				t.Errorf("affectedTargets() mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,458 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/timo-reymann/ContainerHive/internal/buildconfig_resolver"
+	"github.com/timo-reymann/ContainerHive/internal/buildkit"
+	"github.com/timo-reymann/ContainerHive/internal/buildkit/build_context"
+	"github.com/timo-reymann/ContainerHive/internal/buildkit/cache"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+	"github.com/timo-reymann/ContainerHive/internal/docker"
+	"github.com/timo-reymann/ContainerHive/internal/fingerprint"
+	"github.com/timo-reymann/ContainerHive/internal/image_metadata"
+	"github.com/timo-reymann/ContainerHive/internal/registry"
+	"github.com/timo-reymann/ContainerHive/internal/report"
+	"github.com/timo-reymann/ContainerHive/internal/scheduler"
+	"github.com/timo-reymann/ContainerHive/internal/source_date"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+const defaultBuildkitAddr = "unix:///run/buildkit/buildkitd.sock"
+
+type buildOptions struct {
+	BuildkitAddr string
+	Jobs         int
+	Force        bool
+	NoDeps       bool
+	Progress     string
+	Trace        bool
+	Selection    selectionOptions
+	Shard        string
+	ShardWeights []string
+	Exports      []string
+	Staging      string
+	CacheMode    string
+}
+
+// buildkitAddr returns the buildkit address from the flag or env var, then from the project config.
+func (b *buildOptions) buildkitAddr(project *model.ContainerHiveProject) string {
+	if b.BuildkitAddr != "" {
+		return b.BuildkitAddr
+	}
+	if project.Config.Buildkit.Address != "" {
+		return project.Config.Buildkit.Address
+	}
+	return defaultBuildkitAddr
+}
+
+func newBuildCommand(opts *globalOptions) *cobra.Command {
+	buildOpts := &buildOptions{}
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "Render the project and build all images in dependency order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runBuild(cmd.Context(), opts, buildOpts)
+		},
+	}
+	cmd.Flags().IntVarP(&buildOpts.Jobs, "jobs", "j", envIntOrDefault("JOBS", runtime.NumCPU()), "Maximum number of images to build in parallel [$"+envPrefix+"JOBS]")
+	cmd.Flags().StringVar(&buildOpts.Progress, "progress", envOrDefault("PROGRESS", "auto"), "Build progress output, one of "+strings.Join(progressModes, ", ")+" [$"+envPrefix+"PROGRESS]")
+	cmd.Flags().BoolVar(&buildOpts.Trace, "trace", false, "Write the raw buildkit status stream of each build to <report-dir>/<image>-<tag>.trace.json")
+	cmd.Flags().BoolVar(&buildOpts.Force, "force", false, "Rebuild all targets, even if their inputs did not change since the last build")
+	cmd.Flags().BoolVar(&buildOpts.NoDeps, "no-deps", false, "Do not add the dependencies of the selected targets, __hive__/ bases built by other jobs are pulled from the staging registry")
+	buildOpts.Selection.addFlags(cmd)
+	cmd.Flags().StringVar(&buildOpts.Shard, "shard", envOrDefault("SHARD", ""), "Only build shard i of n, e.g. 2/4, every shard builds the dependencies of its tags and variants itself and stages them below shard-i-of-n in the staging registry [$"+envPrefix+"SHARD]")
+	cmd.Flags().StringSliceVar(&buildOpts.ShardWeights, "shard-weights", splitList(envOrDefault("SHARD_WEIGHTS", "")), "Build reports of a previous run to balance shards by build duration, pass the reports of all shards as each one only contains its own targets [$"+envPrefix+"SHARD_WEIGHTS]")
+	cmd.Flags().StringSliceVar(&buildOpts.Exports, "export", splitList(envOrDefault("EXPORT", exportOCITar)), "Outputs of each build, one or more of "+strings.Join(exportTypes, ", ")+" [$"+envPrefix+"EXPORT]")
+	cmd.Flags().StringVar(&buildOpts.Staging, "staging", envOrDefault("STAGING", stagingAuto), "How __hive__/ bases are passed to dependent images, one of "+strings.Join(stagingModes, ", ")+", auto uses the staging registry in CI and OCI layouts otherwise [$"+envPrefix+"STAGING]")
+	cmd.Flags().StringVar(&buildOpts.CacheMode, "cache-mode", envOrDefault("CACHE_MODE", ""), "Override the mode of the configured cache, one of "+strings.Join(cacheModes, ", ")+", e.g. read-only for pull request builds [$"+envPrefix+"CACHE_MODE]")
+	cmd.Flags().StringVar(&buildOpts.BuildkitAddr, "buildkit-addr", envOrDefault("BUILDKIT_ADDR", ""), "Address of the buildkit daemon, defaults to buildkit.address from the project config or "+defaultBuildkitAddr+" [$"+envPrefix+"BUILDKIT_ADDR]")
+	return cmd
+}
+
+// imageBuilder builds targets with buildkit and exports them to the configured outputs.
+// Targets referenced via __hive__/ in the referenced graph are also staged, either in the staging registry
+// or as OCI layout passed to the builds of dependent images as named contexts.
+// Targets whose fingerprint matches the last successful build are carried over from the previous dist directory.
+type imageBuilder struct {
+	client       *buildkit.Client
+	graph        *dependency.Graph
+	referenced   *dependency.Graph
+	registry     registry.Registry
+	cache        *cache.Resolver
+	progress     *progressOutput
+	state        *fingerprint.State
+	distPath     string
+	previousDist string
+	force        bool
+	report       *report.Report
+	exports      []string
+	// staging is the resolved staging mode, empty if no image references another one
+	staging string
+	// targets of the run by name:tag, used to look up the OCI layouts of bases
+	targets map[string]*buildTarget
+	// docker loads images for the docker export, nil if it is not used
+	docker *docker.Client
+	// sourceDateEpochs maps image identifiers to their SOURCE_DATE_EPOCH
+	sourceDateEpochs map[string]int64
+	source           image_metadata.Source
+	// publishRegistry is the target of the push export and qualifies the base image name in the generated labels,
+	// empty if none is configured
+	publishRegistry string
+}
+
+// build builds or reuses the target and records the result in the report.
+func (b *imageBuilder) build(ctx context.Context, target *buildTarget) error {
+	start := time.Now()
+	reused, err := b.buildOrReuse(ctx, target)
+
+	result := report.BuildResult{
+		Status:    report.StatusSucceeded,
+		Duration:  time.Since(start).Seconds(),
+		Platforms: target.Platforms(),
+		Error:     report.ErrorString(err),
+	}
+	if slices.Contains(b.exports, exportOCITar) {
+		result.TarPath = target.TarFile()
+	}
+	result.LogPath, result.TracePath = b.progress.files(target)
+	switch {
+	case err != nil:
+		result.Status = report.StatusFailed
+		result.TarPath = ""
+		if result.LogPath != "" {
+			log.Printf("Build log of %s: %s", target.ImageTag(), result.LogPath)
+		}
+	case reused:
+		result.Status = report.StatusUnchanged
+	}
+	if entry, ok := b.state.Get(target.ImageTag()); ok && err == nil {
+		result.Digest = entry.Digest
+	}
+	b.report.SetBuild(target.reportTarget(), result)
+	return err
+}
+
+// buildOrReuse builds the target unless the image of the previous run can be reused.
+// Reused images are staged from their OCI tar if other targets reference them.
+func (b *imageBuilder) buildOrReuse(ctx context.Context, target *buildTarget) (bool, error) {
+	imageTag := target.ImageTag()
+	if _, err := os.Stat(filepath.Join(target.DistDir, "Dockerfile")); err != nil {
+		return false, errors.Join(fmt.Errorf("dockerfile not found for %s", imageTag), err)
+	}
+
+	buildValues, err := target.ResolveBuildValues()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to resolve build args for %s", imageTag), err)
+	}
+
+	fp, err := b.fingerprint(target, buildValues)
+	if err != nil {
+		return false, err
+	}
+
+	reused, err := b.reusePrevious(target, fp)
+	if err != nil {
+		return false, err
+	}
+	if !reused {
+		b.state.Delete(imageTag)
+		digest, err := b.buildImage(ctx, target, buildValues)
+		if err != nil {
+			return false, err
+		}
+		b.state.Set(imageTag, fingerprint.Entry{Fingerprint: fp, Digest: digest})
+		return false, nil
+	}
+
+	if b.staged(target) {
+		if err := b.stageReused(ctx, target); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// buildImage builds the target with buildkit into its exports and returns the digest of the image.
+func (b *imageBuilder) buildImage(ctx context.Context, target *buildTarget, buildValues *buildconfig_resolver.ResolvedBuildValues) (string, error) {
+	imageTag := target.ImageTag()
+	root, err := filepath.Abs(target.DistDir)
+	if err != nil {
+		return "", err
+	}
+
+	labels, annotations, err := b.imageMetadata(target, buildValues)
+	if err != nil {
+		return "", err
+	}
+
+	contexts, err := b.layoutContexts(target)
+	if err != nil {
+		return "", err
+	}
+
+	buildCache, cacheMode, err := b.targetCache(target)
+	if err != nil {
+		return "", err
+	}
+
+	platforms := target.Platforms()
+	log.Printf("Building %s for %s ...", imageTag, strings.Join(platforms, ", "))
+	digest, err := b.client.Build(ctx, &buildkit.BuildOpts{
+		ImageName: imageTag,
+		Platforms: platforms,
+		Exporters: b.exporters(ctx, target),
+		Cache:     buildCache,
+		CacheMode: cacheMode,
+		BuildContext: &build_context.DockerfileBuildContext{
+			Root:       root,
+			HivePrefix: b.hivePrefix(),
+		},
+		OCILayoutContexts: contexts,
+		BuildArgs:         buildValues.ToBuildArgs(),
+		Secrets:           buildValues.Secrets,
+		SourceDateEpoch:   b.sourceDateEpochs[target.Image.Identifier],
+		Labels:            labels,
+		Annotations:       annotations,
+	}, b.progress.handler(target))
+	if err != nil {
+		return "", errors.Join(fmt.Errorf("build failed for %s", imageTag), err)
+	}
+	log.Printf("Built %s -> %s", imageTag, b.outputs(target))
+	if b.staged(target) && b.staging == stagingRegistry {
+		log.Printf("Pushed %s to registry", imageTag)
+	}
+	return digest, nil
+}
+
+func runBuild(ctx context.Context, opts *globalOptions, buildOpts *buildOptions) error {
+	policy, err := opts.failurePolicy()
+	if err != nil {
+		return err
+	}
+	progressMode, err := parseProgressMode(buildOpts.Progress)
+	if err != nil {
+		return err
+	}
+	if err := buildOpts.Selection.validate(); err != nil {
+		return err
+	}
+	exports, err := parseExportTypes(buildOpts.Exports)
+	if err != nil {
+		return err
+	}
+	staging, err := parseStagingMode(buildOpts.Staging)
+	if err != nil {
+		return err
+	}
+	if err := validateCacheMode(buildOpts.CacheMode); err != nil {
+		return err
+	}
+	var shard *scheduler.Shard
+	if buildOpts.Shard != "" {
+		parsed, err := scheduler.ParseShard(buildOpts.Shard)
+		if err != nil {
+			return err
+		}
+		shard = &parsed
+	}
+
+	project, err := discoverProject(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(exports, exportPush) && project.Config.Registries.Publish == "" {
+		return errors.New("the push export requires registries.publish in the project config")
+	}
+
+	distPath := opts.distPath(project)
+	previousDist, err := preserveDist(distPath)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(previousDist)
+
+	if err := renderProject(ctx, opts, project); err != nil {
+		return err
+	}
+
+	state, err := fingerprint.LoadState(statePath(distPath))
+	if err != nil {
+		return err
+	}
+
+	reportDir := opts.reportPath(project)
+	if err := os.MkdirAll(reportDir, 0755); err != nil {
+		return errors.Join(errors.New("failed to create report directory"), err)
+	}
+	buildReport := report.New()
+
+	graph, err := resolveDependencyGraph(distPath, project)
+	if err != nil {
+		return err
+	}
+	log.Printf("Build order: %v", graph.BuildOrder)
+
+	allTargets := collectTargets(project, distPath, imageNames(project))
+	targets, err := buildOpts.Selection.selectTargets(allTargets, graph.Graph, !buildOpts.NoDeps)
+	if err != nil {
+		return err
+	}
+	if buildOpts.NoDeps {
+		if err := validateNoDeps(staging, project, graph.Scanned); err != nil {
+			return err
+		}
+	}
+	buildGraph := graph.Graph
+	switch {
+	case buildOpts.Selection.active() && buildOpts.NoDeps:
+		buildGraph = graph.Graph.Subgraph(targetKeys(targets))
+		log.Printf("Selected %d of %d target(s), bases of other targets are pulled from the staging registry", len(targets), len(allTargets))
+	case buildOpts.Selection.active():
+		buildGraph = graph.Graph.Subgraph(targetKeys(targets))
+		log.Printf("Selected %d of %d target(s) including their dependencies", len(targets), len(allTargets))
+	}
+	if shard != nil {
+		targets, err = shardTargets(targets, buildGraph, *shard, buildOpts.ShardWeights)
+		if err != nil {
+			return err
+		}
+		buildGraph = buildGraph.Subgraph(targetKeys(targets))
+	}
+
+	sourceDateEpochs, err := source_date.Resolve(project)
+	if err != nil {
+		return errors.Join(errors.New("failed to derive SOURCE_DATE_EPOCH from git history"), err)
+	}
+	source, err := image_metadata.ResolveSource(project.RootDir)
+	if err != nil {
+		return errors.Join(errors.New("failed to read image source from git"), err)
+	}
+
+	buildCache, err := resolveCache(project, buildOpts.CacheMode)
+	if err != nil {
+		return err
+	}
+	if buildCache != nil {
+		log.Printf("Build cache configured: type=%s mode=%s", buildCache.Name(), buildCache.Mode)
+	}
+
+	buildkitAddr := buildOpts.buildkitAddr(project)
+	log.Printf("Connecting to BuildKit at %s ...", buildkitAddr)
+	bkClient, err := buildkit.NewClient(ctx, buildkitAddr)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to connect to BuildKit at %s", buildkitAddr), err)
+	}
+	defer bkClient.Close()
+
+	version, err := bkClient.Version(ctx)
+	if err != nil {
+		return errors.Join(errors.New("failed to get BuildKit version"), err)
+	}
+	log.Printf("BuildKit version: %s", version)
+
+	builder := &imageBuilder{
+		client:           bkClient,
+		graph:            graph.Graph,
+		referenced:       graph.Scanned,
+		cache:            buildCache,
+		state:            state,
+		distPath:         distPath,
+		previousDist:     previousDist,
+		force:            buildOpts.Force,
+		report:           buildReport,
+		exports:          exports,
+		sourceDateEpochs: sourceDateEpochs,
+		source:           source,
+		publishRegistry:  project.Config.Registries.Publish,
+		progress:         newProgressOutput(os.Stdout, progressMode, buildOpts.Jobs > 1, reportDir, buildOpts.Trace),
+	}
+
+	if slices.Contains(exports, exportDocker) {
+		dockerClient, err := docker.NewClient()
+		if err != nil {
+			return errors.Join(errors.New("failed to create Docker client"), err)
+		}
+		defer dockerClient.Close()
+		builder.docker = dockerClient
+	}
+
+	switch {
+	case !graph.Scanned.HasDependencies():
+		log.Println("No inter-image dependencies, building without staging")
+	case staging == stagingOCILayout:
+		log.Println("Passing bases to dependent images as OCI layouts")
+		builder.staging = staging
+	default:
+		reg := registry.NewRegistry(project.Config.Registries.Staging)
+		if err := reg.Start(ctx); err != nil {
+			return errors.Join(errors.New("failed to start registry"), err)
+		}
+		defer reg.Stop(ctx)
+		if namespace := shardNamespace(shard, buildOpts.NoDeps); namespace != "" {
+			reg = registry.WithNamespace(reg, namespace)
+		}
+		log.Printf("Registry started: local=%v address=%s", reg.IsLocal(), reg.Address())
+		builder.staging = staging
+		builder.registry = reg
+	}
+
+	targetsByKey := make(map[string]*buildTarget, len(targets))
+	for _, target := range targets {
+		targetsByKey[target.ImageTag()] = target
+	}
+	builder.targets = targetsByKey
+	if err := carryOverUnselected(distPath, previousDist, allTargets, targetsByKey); err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		if len(target.Aliases) > 0 {
+			log.Printf("Floating tags for %s: %s", target.ImageTag(), strings.Join(target.Aliases, ", "))
+		}
+	}
+
+	log.Printf("Building %d target(s) with up to %d parallel job(s)", len(targets), buildOpts.Jobs)
+	runErr := scheduler.New(buildGraph, buildOpts.Jobs).
+		WithPolicy(policy, func(key, failedDependency string) {
+			log.Printf("Skipping %s, its dependency %s failed", key, failedDependency)
+			buildReport.SetBuild(targetsByKey[key].reportTarget(), report.BuildResult{
+				Status: report.StatusSkipped,
+				Error:  fmt.Sprintf("dependency %s failed", failedDependency),
+			})
+		}).
+		Run(ctx, func(ctx context.Context, key string) error {
+			return builder.build(ctx, targetsByKey[key])
+		})
+	if runErr != nil && ctx.Err() == nil {
+		runErr = errors.Join(errBuildFailed, runErr)
+	}
+
+	for _, target := range targets {
+		if !buildReport.HasBuild(target.reportTarget()) {
+			buildReport.SetBuild(target.reportTarget(), report.BuildResult{Status: report.StatusSkipped, Error: "not started"})
+		}
+	}
+
+	// Record successful builds even if the run failed, so they are skipped next time
+	if err := state.Save(statePath(distPath)); err != nil {
+		return errors.Join(runErr, err)
+	}
+	reportFile := filepath.Join(reportDir, report.FileName)
+	if err := buildReport.Save(reportFile); err != nil {
+		return errors.Join(runErr, err)
+	}
+	log.Printf("Build report written to %s", reportFile)
+	return runErr
+}
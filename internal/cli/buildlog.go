@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/moby/buildkit/client"
+)
+
+const buildLogTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// buildLog writes the steps of a build and their log output as timestamped lines, e.g.
+//
+//	2026-01-02T10:00:00.000Z [2/2] RUN make
+//	2026-01-02T10:00:01.250Z [2/2] RUN make | gcc -o app main.c
+//	2026-01-02T10:00:03.500Z [2/2] RUN make | DONE 3.5s
+type buildLog struct {
+	out      io.Writer
+	vertices map[string]*loggedVertex
+}
+
+type loggedVertex struct {
+	name      string
+	started   bool
+	completed bool
+	// partial holds incomplete log lines per stream
+	partial map[int][]byte
+}
+
+func newBuildLog(out io.Writer) *buildLog {
+	return &buildLog{out: out, vertices: make(map[string]*loggedVertex)}
+}
+
+func (l *buildLog) vertex(key string) *loggedVertex {
+	v, ok := l.vertices[key]
+	if !ok {
+		v = &loggedVertex{name: key, partial: make(map[int][]byte)}
+		l.vertices[key] = v
+	}
+	return v
+}
+
+// WriteStatus logs started and completed steps and the log output of the status.
+func (l *buildLog) WriteStatus(status *client.SolveStatus) error {
+	for _, vertex := range status.Vertexes {
+		v := l.vertex(vertex.Digest.String())
+		if vertex.Name != "" {
+			v.name = vertex.Name
+		}
+
+		if vertex.Started != nil && !v.started {
+			v.started = true
+			if err := l.writeLine(*vertex.Started, v.name, ""); err != nil {
+				return err
+			}
+		}
+		if vertex.Completed == nil || v.completed {
+			continue
+		}
+		v.completed = true
+		if err := l.flushPartial(v, *vertex.Completed); err != nil {
+			return err
+		}
+
+		result := "DONE"
+		switch {
+		case vertex.Error != "":
+			result = "ERROR: " + vertex.Error
+		case vertex.Cached:
+			result = "CACHED"
+		case vertex.Started != nil:
+			result = fmt.Sprintf("DONE %.1fs", vertex.Completed.Sub(*vertex.Started).Seconds())
+		}
+		if err := l.writeLine(*vertex.Completed, v.name, result); err != nil {
+			return err
+		}
+	}
+
+	for _, log := range status.Logs {
+		v := l.vertex(log.Vertex.String())
+		data := append(v.partial[log.Stream], log.Data...)
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			if err := l.writeLine(log.Timestamp, v.name, string(bytes.TrimSuffix(data[:i], []byte("\r")))); err != nil {
+				return err
+			}
+			data = data[i+1:]
+		}
+		v.partial[log.Stream] = data
+	}
+	return nil
+}
+
+// Close writes log output that did not end with a newline.
+func (l *buildLog) Close() error {
+	for _, v := range l.vertices {
+		if err := l.flushPartial(v, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *buildLog) flushPartial(v *loggedVertex, ts time.Time) error {
+	for stream, data := range v.partial {
+		delete(v.partial, stream)
+		if len(data) == 0 {
+			continue
+		}
+		if err := l.writeLine(ts, v.name, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *buildLog) writeLine(ts time.Time, step, message string) error {
+	line := ts.UTC().Format(buildLogTimeFormat) + " " + step
+	if message != "" {
+		line += " | " + message
+	}
+	_, err := io.WriteString(l.out, line+"\n")
+	return err
+}
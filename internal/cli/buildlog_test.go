@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/moby/buildkit/client"
+)
+
+func TestBuildLog(t *testing.T) {
+	started := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	completed := started.Add(3500 * time.Millisecond)
+
+	statuses := []*client.SolveStatus{
+		{Vertexes: []*client.Vertex{
+			{Digest: "sha256:a", Name: "[1/2] FROM docker.io/library/alpine", Started: &started, Completed: &started, Cached: true},
+			{Digest: "sha256:b", Name: "[2/2] RUN make", Started: &started},
+		}},
+		{Logs: []*client.VertexLog{
+			{Vertex: "sha256:b", Data: []byte("gcc -o app"), Timestamp: started.Add(time.Second)},
+			{Vertex: "sha256:b", Data: []byte(" main.c\nwarn"), Timestamp: started.Add(time.Second)},
+			{Vertex: "sha256:b", Stream: 2, Data: []byte("main.c:1: unused variable\r\n"), Timestamp: started.Add(2 * time.Second)},
+		}},
+		{Vertexes: []*client.Vertex{
+			{Digest: "sha256:b", Name: "[2/2] RUN make", Started: &started},
+		}},
+		{Vertexes: []*client.Vertex{
+			{Digest: "sha256:b", Name: "[2/2] RUN make", Started: &started, Completed: &completed, Error: "exit code: 2"},
+		}},
+	}
+
+	var out bytes.Buffer
+	log := newBuildLog(&out)
+	for _, status := range statuses {
+		if err := log.WriteStatus(status); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "2026-01-02T10:00:00.000Z [1/2] FROM docker.io/library/alpine\n" +
+		"2026-01-02T10:00:00.000Z [1/2] FROM docker.io/library/alpine | CACHED\n" +
+		"2026-01-02T10:00:00.000Z [2/2] RUN make\n" +
+		"2026-01-02T10:00:01.000Z [2/2] RUN make | gcc -o app main.c\n" +
+		"2026-01-02T10:00:02.000Z [2/2] RUN make | main.c:1: unused variable\n" +
+		"2026-01-02T10:00:03.500Z [2/2] RUN make | warn\n" +
+		"2026-01-02T10:00:03.500Z [2/2] RUN make | ERROR: exit code: 2\n"
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Errorf("unexpected log (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/timo-reymann/ContainerHive/internal/buildkit/cache"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+// cacheModeOff disables the configured cache.
+const cacheModeOff = "off"
+
+var cacheModes = append(slices.Clone(cache.Modes), cacheModeOff)
+
+// validateCacheMode checks the cache mode override, empty uses the mode from the project config.
+func validateCacheMode(name string) error {
+	if name != "" && !slices.Contains(cacheModes, name) {
+		return fmt.Errorf("unsupported cache mode %q, expected one of %s", name, strings.Join(cacheModes, ", "))
+	}
+	return nil
+}
+
+// resolveCache creates the cache resolver of the project and applies the cache mode override.
+// It returns nil if no cache is configured or the cache is turned off.
+func resolveCache(project *model.ContainerHiveProject, modeOverride string) (*cache.Resolver, error) {
+	if modeOverride == cacheModeOff {
+		return nil, nil
+	}
+	resolver, err := cache.FromConfig(project)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to configure build cache"), err)
+	}
+	if resolver != nil && modeOverride != "" {
+		resolver.Mode = cache.Mode(modeOverride)
+	}
+	return resolver, nil
+}
+
+// targetCache returns the cache of the target and its mode, nil if no cache is used.
+func (b *imageBuilder) targetCache(target *buildTarget) (cache.BuildkitCache, cache.Mode, error) {
+	if b.cache == nil {
+		return nil, "", nil
+	}
+	keyContext := cache.KeyContext{ImageName: target.Image.Name, Tag: target.TagName()}
+	if target.Variant != nil {
+		keyContext.Variant = target.Variant.Name
+	}
+	buildCache, err := b.cache.ForTarget(keyContext)
+	return buildCache, b.cache.Mode, err
+}
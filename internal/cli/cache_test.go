@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/internal/buildkit/cache"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+	"github.com/timo-reymann/ContainerHive/pkg/rendering"
+)
+
+func TestValidateCacheMode(t *testing.T) {
+	for _, mode := range []string{"", "read-write", "read-only", "write-only", "off"} {
+		if err := validateCacheMode(mode); err != nil {
+			t.Errorf("unexpected error for %q: %v", mode, err)
+		}
+	}
+	if err := validateCacheMode("sometimes"); err == nil {
+		t.Error("expected error for unsupported mode")
+	}
+}
+
+func TestResolveCache(t *testing.T) {
+	project := &model.ContainerHiveProject{
+		RootDir: "/project",
+		Config: &model.HiveProjectConfig{
+			Cache: &model.CacheConfig{Type: "local", Mode: "read-only", Local: &model.LocalCacheConfig{Dir: ".cache"}},
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		modeOverride string
+		expectNil    bool
+		expectedMode cache.Mode
+	}{
+		{name: "mode from config", expectedMode: cache.ModeReadOnly},
+		{name: "mode override", modeOverride: "write-only", expectedMode: cache.ModeWriteOnly},
+		{name: "off", modeOverride: "off", expectNil: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver, err := resolveCache(project, tc.modeOverride)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectNil {
+				if resolver != nil {
+					t.Fatal("expected no cache")
+				}
+				return
+			}
+			if resolver.Mode != tc.expectedMode {
+				t.Errorf("expected mode %s, got %s", tc.expectedMode, resolver.Mode)
+			}
+		})
+	}
+}
+
+func TestImageBuilder_TargetCache(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/dependency-project")
+	dist := filepath.Join(t.TempDir(), "dist")
+	if err := rendering.RenderProject(t.Context(), project, dist); err != nil {
+		t.Fatal(err)
+	}
+	target := collectTargets(project, dist, []string{"ubuntu"})[0]
+
+	t.Run("no cache configured", func(t *testing.T) {
+		builder := &imageBuilder{}
+		buildCache, mode, err := builder.targetCache(target)
+		if err != nil || buildCache != nil || mode != "" {
+			t.Errorf("expected no cache, got %v %q (%v)", buildCache, mode, err)
+		}
+	})
+
+	t.Run("cache per target", func(t *testing.T) {
+		project.Config.Cache = &model.CacheConfig{
+			Type:     "registry",
+			Mode:     "read-only",
+			Registry: &model.RegistryCacheConfig{Ref: "registry.example.com/cache"},
+		}
+		defer func() { project.Config.Cache = nil }()
+		resolver, err := resolveCache(project, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		builder := &imageBuilder{cache: resolver}
+		buildCache, mode, err := builder.targetCache(target)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := cache.RegistryCache{CacheRef: "registry.example.com/cache:ubuntu-" + target.TagName()}
+		if diff := cmp.Diff(expected, buildCache); diff != "" {
+			t.Errorf("targetCache() mismatch (-expected +got):\n%s", diff)
+		}
+		if mode != cache.ModeReadOnly {
+			t.Errorf("expected mode read-only, got %s", mode)
+		}
+	})
+}
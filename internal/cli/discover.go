@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func newDiscoverCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "discover",
+		Short: "Discover all images of the project and print a summary",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			project, err := discoverProject(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "IDENTIFIER\tNAME\tTAGS\tVARIANTS")
+			for _, identifier := range slices.Sorted(maps.Keys(project.ImagesByIdentifier)) {
+				image := project.ImagesByIdentifier[identifier]
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+					identifier,
+					image.Name,
+					strings.Join(slices.Sorted(maps.Keys(image.Tags)), ","),
+					strings.Join(slices.Sorted(maps.Keys(image.Variants)), ","),
+				)
+			}
+			return w.Flush()
+		},
+	}
+}
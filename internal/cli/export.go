@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/timo-reymann/ContainerHive/internal/buildkit/exporter"
+)
+
+const (
+	exportOCITar    = "oci-tar"
+	exportOCILayout = "oci-layout"
+	exportDocker    = "docker"
+	exportPush      = "push"
+)
+
+var exportTypes = []string{exportOCITar, exportOCILayout, exportDocker, exportPush}
+
+// parseExportTypes validates the export types and removes duplicates.
+func parseExportTypes(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no export configured, expected one or more of %s", strings.Join(exportTypes, ", "))
+	}
+	var exports []string
+	for _, name := range names {
+		if !slices.Contains(exportTypes, name) {
+			return nil, fmt.Errorf("unsupported export %q, expected one of %s", name, strings.Join(exportTypes, ", "))
+		}
+		if !slices.Contains(exports, name) {
+			exports = append(exports, name)
+		}
+	}
+	return exports, nil
+}
+
+// reusable reports whether unchanged targets can be carried over from the previous run. This requires the OCI tar
+// and is not possible when images are loaded into docker or pushed, as those outputs live outside the dist directory.
+func (b *imageBuilder) reusable() bool {
+	return slices.Contains(b.exports, exportOCITar) &&
+		!slices.Contains(b.exports, exportDocker) &&
+		!slices.Contains(b.exports, exportPush)
+}
+
+// exporters returns the buildkit exporters of the target. Targets referenced by other images
+// are additionally pushed to the staging registry or written to their OCI layout directory.
+func (b *imageBuilder) exporters(ctx context.Context, target *buildTarget) []exporter.BuildkitExporter {
+	var exporters []exporter.BuildkitExporter
+	for _, export := range b.exports {
+		switch export {
+		case exportOCITar:
+			exporters = append(exporters, exporter.OCITar{Path: target.TarFile()})
+		case exportOCILayout:
+			exporters = append(exporters, exporter.OCILayout{Dir: target.LayoutDir()})
+		case exportDocker:
+			exporters = append(exporters, exporter.DockerLoad{Load: func(r io.Reader) error {
+				return b.docker.LoadImage(ctx, r)
+			}})
+		case exportPush:
+			refs := make([]string, 0, len(target.PublishTags()))
+			for _, tag := range target.PublishTags() {
+				refs = append(refs, b.publishRegistry+"/"+target.Image.Name+":"+tag)
+			}
+			exporters = append(exporters, exporter.RegistryPush{Refs: refs})
+		}
+	}
+
+	if b.staged(target) {
+		switch b.staging {
+		case stagingRegistry:
+			exporters = append(exporters, exporter.RegistryPush{
+				Refs:     []string{b.registry.Address() + "/" + target.ImageTag()},
+				Insecure: b.registry.IsLocal(),
+			})
+		case stagingOCILayout:
+			if !slices.Contains(b.exports, exportOCILayout) {
+				exporters = append(exporters, exporter.OCILayout{Dir: target.LayoutDir()})
+			}
+		}
+	}
+	return exporters
+}
+
+// outputs describes where the target was exported to for the build log.
+func (b *imageBuilder) outputs(target *buildTarget) string {
+	outputs := make([]string, 0, len(b.exports))
+	for _, export := range b.exports {
+		switch export {
+		case exportOCITar:
+			outputs = append(outputs, target.TarFile())
+		case exportOCILayout:
+			outputs = append(outputs, target.LayoutDir())
+		case exportDocker:
+			outputs = append(outputs, "docker")
+		case exportPush:
+			outputs = append(outputs, b.publishRegistry)
+		}
+	}
+	return strings.Join(outputs, ", ")
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/internal/buildkit/exporter"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+	"github.com/timo-reymann/ContainerHive/internal/registry"
+	"github.com/timo-reymann/ContainerHive/pkg/rendering"
+)
+
+func TestParseExportTypes(t *testing.T) {
+	testCases := []struct {
+		name      string
+		exports   []string
+		expected  []string
+		expectErr bool
+	}{
+		{name: "default", exports: []string{"oci-tar"}, expected: []string{"oci-tar"}},
+		{name: "all", exports: exportTypes, expected: exportTypes},
+		{name: "duplicates", exports: []string{"docker", "oci-tar", "docker"}, expected: []string{"docker", "oci-tar"}},
+		{name: "unsupported", exports: []string{"oci-tar", "zip"}, expectErr: true},
+		{name: "empty", exports: nil, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			exports, err := parseExportTypes(tc.exports)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, exports); diff != "" {
+				t.Errorf("parseExportTypes() mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestImageBuilder_Exporters(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/dependency-project")
+	dist := filepath.Join(t.TempDir(), "dist")
+	if err := rendering.RenderProject(t.Context(), project, dist); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := make(map[string]*buildTarget)
+	for _, target := range collectTargets(project, dist, imageNames(project)) {
+		targets[target.ImageTag()] = target
+	}
+	ubuntu := targets["ubuntu:22.04"]
+	ubuntu.Aliases = []string{"latest"}
+	python := targets["python:3.13"]
+
+	referenced := dependency.NewGraph()
+	referenced.AddImage("ubuntu:22.04")
+	referenced.AddImage("python:3.13")
+	referenced.AddDependency("python:3.13", "ubuntu:22.04")
+
+	builder := &imageBuilder{
+		referenced:      referenced,
+		staging:         stagingRegistry,
+		registry:        registry.NewRemoteRegistry("staging.example.com"),
+		exports:         []string{exportOCITar, exportOCILayout, exportPush},
+		publishRegistry: "registry.example.com/library",
+	}
+
+	t.Run("referenced target is staged", func(t *testing.T) {
+		expected := []exporter.BuildkitExporter{
+			exporter.OCITar{Path: ubuntu.TarFile()},
+			exporter.OCILayout{Dir: ubuntu.LayoutDir()},
+			exporter.RegistryPush{Refs: []string{
+				"registry.example.com/library/ubuntu:22.04",
+				"registry.example.com/library/ubuntu:latest",
+			}},
+			exporter.RegistryPush{Refs: []string{"staging.example.com/ubuntu:22.04"}},
+		}
+		if diff := cmp.Diff(expected, builder.exporters(t.Context(), ubuntu)); diff != "" {
+			t.Errorf("exporters() mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("referenced target is staged as OCI layout", func(t *testing.T) {
+		builder.staging = stagingOCILayout
+		builder.exports = []string{exportOCITar}
+		defer func() {
+			builder.staging = stagingRegistry
+			builder.exports = []string{exportOCITar, exportOCILayout, exportPush}
+		}()
+		expected := []exporter.BuildkitExporter{
+			exporter.OCITar{Path: ubuntu.TarFile()},
+			exporter.OCILayout{Dir: ubuntu.LayoutDir()},
+		}
+		if diff := cmp.Diff(expected, builder.exporters(t.Context(), ubuntu)); diff != "" {
+			t.Errorf("exporters() mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("unreferenced target is not staged", func(t *testing.T) {
+		builder.exports = []string{exportOCITar}
+		defer func() { builder.exports = []string{exportOCITar, exportOCILayout, exportPush} }()
+		expected := []exporter.BuildkitExporter{exporter.OCITar{Path: python.TarFile()}}
+		if diff := cmp.Diff(expected, builder.exporters(t.Context(), python)); diff != "" {
+			t.Errorf("exporters() mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("docker export loads into the daemon", func(t *testing.T) {
+		builder.exports = []string{exportDocker}
+		defer func() { builder.exports = []string{exportOCITar, exportOCILayout, exportPush} }()
+		exporters := builder.exporters(t.Context(), python)
+		if len(exporters) != 1 {
+			t.Fatalf("expected one exporter, got %d", len(exporters))
+		}
+		if _, ok := exporters[0].(exporter.DockerLoad); !ok {
+			t.Errorf("expected docker exporter, got %T", exporters[0])
+		}
+	})
+}
+
+func TestImageBuilder_Reusable(t *testing.T) {
+	testCases := []struct {
+		exports  []string
+		expected bool
+	}{
+		{exports: []string{exportOCITar}, expected: true},
+		{exports: []string{exportOCITar, exportOCILayout}, expected: true},
+		{exports: []string{exportOCILayout}, expected: false},
+		{exports: []string{exportOCITar, exportDocker}, expected: false},
+		{exports: []string{exportOCITar, exportPush}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		builder := &imageBuilder{exports: tc.exports}
+		if got := builder.reusable(); got != tc.expected {
+			t.Errorf("reusable() for %v = %v, expected %v", tc.exports, got, tc.expected)
+		}
+	}
+}
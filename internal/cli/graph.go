@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+)
+
+var graphFormats = []string{"text", "dot", "mermaid", "json"}
+
+type graphOptions struct {
+	Format string
+	Levels bool
+}
+
+func newGraphCommand(opts *globalOptions) *cobra.Command {
+	graphOpts := &graphOptions{}
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Print or export the dependency graph of all tags and variants without touching the build output",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !slices.Contains(graphFormats, graphOpts.Format) {
+				return fmt.Errorf("unsupported graph format %q, expected one of %s", graphOpts.Format, strings.Join(graphFormats, ", "))
+			}
+
+			project, err := discoverProject(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			distPath, cleanup, err := renderTemporary(cmd.Context(), project)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			graph, err := resolveDependencyGraph(distPath, project)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			exportOpts := dependency.ExportOptions{Levels: graphOpts.Levels}
+			switch graphOpts.Format {
+			case "dot":
+				return graph.Graph.WriteDOT(out, exportOpts)
+			case "mermaid":
+				return graph.Graph.WriteMermaid(out, exportOpts)
+			case "json":
+				return graph.Graph.WriteJSON(out, exportOpts)
+			default:
+				return writeGraphText(out, graph, graphOpts.Levels)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&graphOpts.Format, "format", envOrDefault("GRAPH_FORMAT", "text"), "Output format, one of "+strings.Join(graphFormats, ", ")+" [$"+envPrefix+"GRAPH_FORMAT]")
+	cmd.Flags().BoolVar(&graphOpts.Levels, "levels", false, "Group the targets into build levels that can be built in parallel")
+	return cmd
+}
+
+// writeGraphText prints each target with its dependencies in build order, optionally grouped by build level.
+func writeGraphText(out io.Writer, graph *projectGraph, levels bool) error {
+	groups := [][]string{graph.BuildOrder}
+	if levels {
+		var err error
+		if groups, err = graph.Graph.Levels(); err != nil {
+			return err
+		}
+	}
+
+	for i, targets := range groups {
+		if levels {
+			_, _ = fmt.Fprintf(out, "Level %d:\n", i)
+		}
+		for _, name := range targets {
+			if levels {
+				_, _ = fmt.Fprint(out, "  ")
+			}
+			deps := slices.Compact(slices.Sorted(slices.Values(graph.Graph.Dependencies(name))))
+			if len(deps) == 0 {
+				_, _ = fmt.Fprintln(out, name)
+				continue
+			}
+			_, _ = fmt.Fprintf(out, "%s -> %s\n", name, strings.Join(deps, ", "))
+		}
+	}
+	return nil
+}
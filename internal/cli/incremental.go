@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/timo-reymann/ContainerHive/internal/buildconfig_resolver"
+	"github.com/timo-reymann/ContainerHive/internal/fingerprint"
+)
+
+// statePath returns the path of the build state file, which lives next to the dist directory
+// because rendering replaces the dist directory.
+func statePath(distPath string) string {
+	return filepath.Clean(distPath) + ".state.json"
+}
+
+// preserveDist moves the dist directory of the previous run aside before rendering,
+// so the outputs of unchanged targets can be carried over. It returns an empty path if there is no previous run.
+func preserveDist(distPath string) (string, error) {
+	previous := filepath.Clean(distPath) + ".previous"
+	if err := os.RemoveAll(previous); err != nil {
+		return "", errors.Join(errors.New("failed to remove outputs of the previous run"), err)
+	}
+	if err := os.Rename(distPath, previous); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", errors.Join(errors.New("failed to preserve outputs of the previous run"), err)
+	}
+	return previous, nil
+}
+
+// carryOverUnselected moves the outputs of targets left out by --only, --exclude or --shard from the previous run
+// into the rendered dist directory, so a partial build keeps them. Outputs are all files and directories of the
+// target the rendering did not create, e.g. the OCI tar and layout and the SBOMs.
+func carryOverUnselected(distPath, previousDist string, all []*buildTarget, selected map[string]*buildTarget) error {
+	if previousDist == "" {
+		return nil
+	}
+
+	for _, target := range all {
+		if _, ok := selected[target.ImageTag()]; ok {
+			continue
+		}
+		rel, err := filepath.Rel(distPath, target.DistDir)
+		if err != nil {
+			return err
+		}
+		previousDir := filepath.Join(previousDist, rel)
+		entries, err := os.ReadDir(previousDir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to read outputs of %s from the previous run", target.ImageTag()), err)
+		}
+
+		for _, entry := range entries {
+			output := filepath.Join(target.DistDir, entry.Name())
+			// Rendered files are always taken from the current run
+			if _, err := os.Lstat(output); err == nil {
+				continue
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			if err := os.Rename(filepath.Join(previousDir, entry.Name()), output); err != nil {
+				return errors.Join(fmt.Errorf("failed to carry over outputs of %s", target.ImageTag()), err)
+			}
+		}
+	}
+	return nil
+}
+
+// fingerprint computes the input fingerprint of the target. Dependencies built in this run have finished before the
+// target is built, so the state already contains the digests of their images from this run.
+func (b *imageBuilder) fingerprint(target *buildTarget, values *buildconfig_resolver.ResolvedBuildValues) (string, error) {
+	baseDigests := make(map[string]string)
+	for _, dep := range b.graph.Dependencies(target.ImageTag()) {
+		entry, _ := b.state.Get(dep)
+		baseDigests[dep] = entry.Digest
+	}
+
+	fp, err := fingerprint.Compute(&fingerprint.Inputs{
+		DistDir:         target.DistDir,
+		BuildArgs:       values.ToBuildArgs(),
+		Versions:        values.Versions,
+		SecretNames:     slices.Collect(maps.Keys(values.Secrets)),
+		Platforms:       target.Platforms(),
+		BaseDigests:     baseDigests,
+		SourceDateEpoch: b.sourceDateEpochs[target.Image.Identifier],
+		Labels:          values.Labels,
+		Annotations:     values.Annotations,
+	})
+	if err != nil {
+		return "", errors.Join(fmt.Errorf("failed to fingerprint %s", target.ImageTag()), err)
+	}
+	return fp, nil
+}
+
+// reusePrevious carries the outputs of the previous run over if the fingerprint matches its last successful build.
+func (b *imageBuilder) reusePrevious(target *buildTarget, fp string) (bool, error) {
+	if b.force || b.previousDist == "" || !b.reusable() {
+		return false, nil
+	}
+
+	if !b.basesBuilt(target) {
+		log.Printf("Rebuilding %s, its bases are pulled from the staging registry and may have changed", target.ImageTag())
+		return false, nil
+	}
+
+	entry, ok := b.state.Get(target.ImageTag())
+	if !ok || entry.Fingerprint != fp {
+		return false, nil
+	}
+
+	outputs := []string{target.TarFile()}
+	if slices.Contains(b.exports, exportOCILayout) {
+		outputs = append(outputs, target.LayoutDir())
+	}
+	previousOutputs := make([]string, len(outputs))
+	for i, output := range outputs {
+		rel, err := filepath.Rel(b.distPath, output)
+		if err != nil {
+			return false, err
+		}
+		previousOutputs[i] = filepath.Join(b.previousDist, rel)
+		if _, err := os.Stat(previousOutputs[i]); err != nil {
+			return false, nil
+		}
+	}
+
+	for i, output := range outputs {
+		if err := os.Rename(previousOutputs[i], output); err != nil {
+			return false, errors.Join(fmt.Errorf("failed to carry over %s", previousOutputs[i]), err)
+		}
+	}
+	log.Printf("Skipping %s, inputs unchanged since the last build", target.ImageTag())
+	return true, nil
+}
+
+// basesBuilt reports whether all dependencies of the target are part of this run. Otherwise, e.g. with --no-deps,
+// the state only holds the digests of their last local build and not of the images pulled from the staging registry.
+func (b *imageBuilder) basesBuilt(target *buildTarget) bool {
+	for _, dep := range b.graph.Dependencies(target.ImageTag()) {
+		if _, ok := b.targets[dep]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+	"github.com/timo-reymann/ContainerHive/internal/fingerprint"
+	"github.com/timo-reymann/ContainerHive/pkg/rendering"
+)
+
+func TestPreserveDist(t *testing.T) {
+	dist := filepath.Join(t.TempDir(), "dist")
+
+	previous, err := preserveDist(dist)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if previous != "" {
+		t.Errorf("expected no previous dist for first run, got %q", previous)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dist, "nginx", "1.27"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	previous, err = preserveDist(dist)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if previous != dist+".previous" {
+		t.Errorf("unexpected previous dist %q", previous)
+	}
+	if _, err := os.Stat(filepath.Join(previous, "nginx", "1.27")); err != nil {
+		t.Errorf("expected outputs to be moved: %v", err)
+	}
+	if _, err := os.Stat(dist); err == nil {
+		t.Error("expected dist to be moved")
+	}
+}
+
+func TestCarryOverUnselected(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/dependency-project")
+	dist := filepath.Join(t.TempDir(), "dist")
+	if err := rendering.RenderProject(t.Context(), project, dist); err != nil {
+		t.Fatal(err)
+	}
+
+	allTargets := collectTargets(project, dist, imageNames(project))
+	targets := make(map[string]*buildTarget)
+	for _, target := range allTargets {
+		targets[target.ImageTag()] = target
+		if err := os.WriteFile(target.TarFile(), []byte("previous "+target.ImageTag()), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(target.TarFile()+".sbom.spdx.json", []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(target.DistDir, "Dockerfile"), []byte("FROM previous"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ubuntu := targets["ubuntu:22.04"]
+	python := targets["python:3.13"]
+
+	previousDist, err := preserveDist(dist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rendering.RenderProject(t.Context(), project, dist); err != nil {
+		t.Fatal(err)
+	}
+
+	selected := map[string]*buildTarget{python.ImageTag(): python}
+	if err := carryOverUnselected(dist, previousDist, allTargets, selected); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("keeps outputs of unselected targets", func(t *testing.T) {
+		content, err := os.ReadFile(ubuntu.TarFile())
+		if err != nil || string(content) != "previous ubuntu:22.04" {
+			t.Errorf("expected tar of the previous run, got %q (%v)", content, err)
+		}
+		if _, err := os.Stat(ubuntu.TarFile() + ".sbom.spdx.json"); err != nil {
+			t.Errorf("expected SBOM of the previous run: %v", err)
+		}
+	})
+
+	t.Run("keeps rendered files of the current run", func(t *testing.T) {
+		content, err := os.ReadFile(filepath.Join(ubuntu.DistDir, "Dockerfile"))
+		if err != nil || string(content) == "FROM previous" {
+			t.Errorf("expected rendered Dockerfile, got %q (%v)", content, err)
+		}
+	})
+
+	t.Run("leaves selected targets to the build", func(t *testing.T) {
+		if _, err := os.Stat(python.TarFile()); err == nil {
+			t.Error("expected tar of selected target not to be carried over")
+		}
+	})
+
+	t.Run("no previous run", func(t *testing.T) {
+		if err := carryOverUnselected(dist, "", allTargets, selected); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestImageBuilder_Incremental(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/dependency-project")
+	dist := filepath.Join(t.TempDir(), "dist")
+	if err := rendering.RenderProject(t.Context(), project, dist); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := make(map[string]*buildTarget)
+	for _, target := range collectTargets(project, dist, imageNames(project)) {
+		targets[target.ImageTag()] = target
+	}
+	python := targets["python:3.13"]
+
+	graph := dependency.NewGraph()
+	graph.AddImage("ubuntu:22.04")
+	graph.AddImage("python:3.13")
+	graph.AddDependency("python:3.13", "ubuntu:22.04")
+
+	previousDist := t.TempDir()
+	builder := &imageBuilder{
+		graph:        graph,
+		state:        fingerprint.NewState(),
+		distPath:     dist,
+		previousDist: previousDist,
+		exports:      []string{exportOCITar},
+		targets:      targets,
+	}
+	builder.state.Set("ubuntu:22.04", fingerprint.Entry{Digest: "sha256:aaaa"})
+
+	values, err := python.ResolveBuildValues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp, err := builder.fingerprint(python, values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("rebuilt base changes fingerprint", func(t *testing.T) {
+		builder.state.Set("ubuntu:22.04", fingerprint.Entry{Digest: "sha256:bbbb"})
+		defer builder.state.Set("ubuntu:22.04", fingerprint.Entry{Digest: "sha256:aaaa"})
+		changed, err := builder.fingerprint(python, values)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if changed == fp {
+			t.Error("expected fingerprint to change with the base digest")
+		}
+	})
+
+	t.Run("rebuilds without state", func(t *testing.T) {
+		if reused, err := builder.reusePrevious(python, fp); err != nil || reused {
+			t.Errorf("expected rebuild, got reused=%v err=%v", reused, err)
+		}
+	})
+
+	builder.state.Set("python:3.13", fingerprint.Entry{Fingerprint: fp, Digest: "sha256:cccc"})
+
+	t.Run("rebuilds without previous output", func(t *testing.T) {
+		if reused, err := builder.reusePrevious(python, fp); err != nil || reused {
+			t.Errorf("expected rebuild, got reused=%v err=%v", reused, err)
+		}
+	})
+
+	previousTar := filepath.Join(previousDist, "python", "3.13", "image.tar")
+	if err := os.MkdirAll(filepath.Dir(previousTar), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(previousTar, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("rebuilds when forced", func(t *testing.T) {
+		builder.force = true
+		defer func() { builder.force = false }()
+		if reused, err := builder.reusePrevious(python, fp); err != nil || reused {
+			t.Errorf("expected rebuild, got reused=%v err=%v", reused, err)
+		}
+	})
+
+	t.Run("rebuilds when loading into docker", func(t *testing.T) {
+		builder.exports = []string{exportOCITar, exportDocker}
+		defer func() { builder.exports = []string{exportOCITar} }()
+		if reused, err := builder.reusePrevious(python, fp); err != nil || reused {
+			t.Errorf("expected rebuild, got reused=%v err=%v", reused, err)
+		}
+	})
+
+	t.Run("rebuilds without previous OCI layout", func(t *testing.T) {
+		builder.exports = []string{exportOCITar, exportOCILayout}
+		defer func() { builder.exports = []string{exportOCITar} }()
+		if reused, err := builder.reusePrevious(python, fp); err != nil || reused {
+			t.Errorf("expected rebuild, got reused=%v err=%v", reused, err)
+		}
+	})
+
+	t.Run("rebuilds on changed fingerprint", func(t *testing.T) {
+		if reused, err := builder.reusePrevious(python, "sha256:other"); err != nil || reused {
+			t.Errorf("expected rebuild, got reused=%v err=%v", reused, err)
+		}
+	})
+
+	t.Run("rebuilds when bases are not built in this run", func(t *testing.T) {
+		builder.targets = map[string]*buildTarget{"python:3.13": python}
+		defer func() { builder.targets = targets }()
+		if reused, err := builder.reusePrevious(python, fp); err != nil || reused {
+			t.Errorf("expected rebuild, got reused=%v err=%v", reused, err)
+		}
+	})
+
+	t.Run("carries over unchanged target", func(t *testing.T) {
+		reused, err := builder.reusePrevious(python, fp)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reused {
+			t.Fatal("expected previous output to be reused")
+		}
+		content, err := os.ReadFile(python.TarFile())
+		if err != nil || string(content) != "previous" {
+			t.Errorf("expected carried over tar, got %q (%v)", content, err)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/timo-reymann/ContainerHive/internal/buildinfo"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+	"github.com/timo-reymann/ContainerHive/internal/scheduler"
+	"github.com/timo-reymann/ContainerHive/pkg/discovery"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+	"github.com/timo-reymann/ContainerHive/pkg/rendering"
+)
+
+const envPrefix = "CONTAINER_HIVE_"
+
+// Exit codes of the ch command, failures of later pipeline stages take precedence.
+const (
+	exitError       = 1
+	exitBuildFailed = 2
+	exitTestFailed  = 3
+	exitSBOMFailed  = 4
+)
+
+var (
+	errBuildFailed = errors.New("one or more builds failed")
+	errTestFailed  = errors.New("one or more container-structure-tests failed")
+	errSBOMFailed  = errors.New("one or more SBOMs could not be generated")
+)
+
+// exitCode maps the error returned by a command to the process exit code.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errSBOMFailed):
+		return exitSBOMFailed
+	case errors.Is(err, errTestFailed):
+		return exitTestFailed
+	case errors.Is(err, errBuildFailed):
+		return exitBuildFailed
+	default:
+		return exitError
+	}
+}
+
+// globalOptions holds the settings shared by all subcommands.
+type globalOptions struct {
+	ProjectRoot   string
+	DistDir       string
+	ReportDir     string
+	FailurePolicy string
+}
+
+// failurePolicy returns the validated failure policy.
+func (o *globalOptions) failurePolicy() (scheduler.FailurePolicy, error) {
+	return scheduler.ParseFailurePolicy(o.FailurePolicy)
+}
+
+// projectPath resolves a directory setting: the flag or env var wins, then the value from the
+// project config relative to the project root, then the fallback inside the project root.
+func (o *globalOptions) projectPath(flagValue, configValue, fallback string, project *model.ContainerHiveProject) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if configValue != "" {
+		if filepath.IsAbs(configValue) {
+			return configValue
+		}
+		return filepath.Join(project.RootDir, configValue)
+	}
+	return filepath.Join(o.ProjectRoot, fallback)
+}
+
+// distPath returns the dist directory, defaulting to <project>/dist.
+func (o *globalOptions) distPath(project *model.ContainerHiveProject) string {
+	return o.projectPath(o.DistDir, project.Config.DistDir, "dist", project)
+}
+
+// reportPath returns the report directory, defaulting to <project>/reports.
+func (o *globalOptions) reportPath(project *model.ContainerHiveProject) string {
+	return o.projectPath(o.ReportDir, project.Config.ReportDir, "reports", project)
+}
+
+// envIntOrDefault returns the CONTAINER_HIVE_ prefixed environment variable parsed as int
+// or the fallback if it is unset or not a valid number.
+func envIntOrDefault(key string, fallback int) int {
+	val, err := strconv.Atoi(envOrDefault(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return val
+}
+
+// envOrDefault returns the value of the CONTAINER_HIVE_ prefixed environment variable or the fallback if it is unset.
+func envOrDefault(key, fallback string) string {
+	if val, ok := os.LookupEnv(envPrefix + key); ok && val != "" {
+		return val
+	}
+	return fallback
+}
+
+func newRootCommand() *cobra.Command {
+	opts := &globalOptions{}
+
+	root := &cobra.Command{
+		Use:           "ch",
+		Short:         "Swarm it. Build it. Run it. — Manage container base and library images",
+		Version:       buildinfo.Version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVarP(&opts.ProjectRoot, "project", "p", envOrDefault("PROJECT", "."), "Root directory of the ContainerHive project [$"+envPrefix+"PROJECT]")
+	flags.StringVar(&opts.DistDir, "dist", envOrDefault("DIST_DIR", ""), "Directory to render the project to, defaults to dist_dir from the project config or <project>/dist [$"+envPrefix+"DIST_DIR]")
+	flags.StringVar(&opts.ReportDir, "report-dir", envOrDefault("REPORT_DIR", ""), "Directory to write reports to, defaults to report_dir from the project config or <project>/reports [$"+envPrefix+"REPORT_DIR]")
+	flags.StringVar(&opts.FailurePolicy, "failure-policy", envOrDefault("FAILURE_POLICY", string(scheduler.FailFast)), "How to proceed after a build, test or SBOM failed: fail-fast, continue, which still builds dependents of failed images, or skip-dependents [$"+envPrefix+"FAILURE_POLICY]")
+
+	root.AddCommand(
+		newDiscoverCommand(opts),
+		newRenderCommand(opts),
+		newGraphCommand(opts),
+		newPlanCommand(opts),
+		newAffectedCommand(opts),
+		newCIMatrixCommand(opts),
+		newBuildCommand(opts),
+		newTestCommand(opts),
+		newSBOMCommand(opts),
+		newPublishCommand(opts),
+	)
+
+	return root
+}
+
+// Execute runs the ch command line interface and returns the process exit code.
+func Execute(ctx context.Context) int {
+	err := newRootCommand().ExecuteContext(ctx)
+	if err != nil {
+		log.Printf("Error: %v", err)
+	}
+	return exitCode(err)
+}
+
+func discoverProject(ctx context.Context, opts *globalOptions) (*model.ContainerHiveProject, error) {
+	project, err := discovery.DiscoverProject(ctx, opts.ProjectRoot)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to discover project"), err)
+	}
+	log.Printf("Discovered %d image(s) in project %s", len(project.ImagesByIdentifier), project.RootDir)
+	return project, nil
+}
+
+func renderProject(ctx context.Context, opts *globalOptions, project *model.ContainerHiveProject) error {
+	distPath := opts.distPath(project)
+	if err := rendering.RenderProject(ctx, project, distPath); err != nil {
+		return errors.Join(errors.New("failed to render project"), err)
+	}
+	log.Println("Rendered project to", distPath)
+	return nil
+}
+
+// renderTemporary renders the project into a temporary directory, so the images of the last build stay in place.
+// The returned function removes the directory.
+func renderTemporary(ctx context.Context, project *model.ContainerHiveProject) (string, func(), error) {
+	distPath, err := os.MkdirTemp("", "container-hive-")
+	if err != nil {
+		return "", nil, errors.Join(errors.New("failed to create temporary render directory"), err)
+	}
+	cleanup := func() { os.RemoveAll(distPath) }
+	if err := rendering.RenderProject(ctx, project, distPath); err != nil {
+		cleanup()
+		return "", nil, errors.Join(errors.New("failed to render project"), err)
+	}
+	return distPath, cleanup, nil
+}
+
+// projectGraph is the build graph of all tags and variants of a project.
+type projectGraph struct {
+	// Graph contains the referenced and the explicitly declared dependencies
+	Graph *dependency.Graph
+	// Scanned only contains the __hive__/ references, referenced targets have to be staged in the registry
+	Scanned    *dependency.Graph
+	BuildOrder []string
+}
+
+// targetBuildArgs returns the build args of all targets keyed by their graph node,
+// they are required to expand ARGs in __hive__/ references. Secrets are not resolved.
+func targetBuildArgs(project *model.ContainerHiveProject, distPath string) map[string]model.BuildArgs {
+	buildArgs := make(map[string]model.BuildArgs)
+	for _, target := range collectTargets(project, distPath, imageNames(project)) {
+		buildArgs[target.ImageTag()] = target.BuildArgs()
+	}
+	return buildArgs
+}
+
+// resolveDependencyGraph scans the rendered project for __hive__/ references, merges them with
+// the explicit depends_on declarations and returns the graph together with the build order.
+func resolveDependencyGraph(distPath string, project *model.ContainerHiveProject) (*projectGraph, error) {
+	scannedGraph, err := dependency.ScanRenderedProject(distPath, targetBuildArgs(project, distPath))
+	if err != nil {
+		return nil, errors.Join(errors.New("dependency scanning failed"), err)
+	}
+
+	graph, err := dependency.BuildDependencyGraph(scannedGraph, project)
+	if err != nil {
+		return nil, errors.Join(errors.New("dependency graph construction failed"), err)
+	}
+
+	buildOrder, err := graph.TopologicalSort()
+	if err != nil {
+		return nil, errors.Join(errors.New("dependency resolution failed"), err)
+	}
+
+	return &projectGraph{
+		Graph:      graph,
+		Scanned:    scannedGraph,
+		BuildOrder: buildOrder,
+	}, nil
+}
@@ -0,0 +1,283 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/internal/scheduler"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+func executeCommand(t *testing.T, args ...string) string {
+	t.Helper()
+	root := newRootCommand()
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs(args)
+	if err := root.ExecuteContext(t.Context()); err != nil {
+		t.Fatalf("command %v failed: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestEnvOrDefault(t *testing.T) {
+	t.Run("returns fallback when unset", func(t *testing.T) {
+		t.Setenv(envPrefix+"SOME_KEY", "")
+		if got := envOrDefault("SOME_KEY", "fallback"); got != "fallback" {
+			t.Errorf("expected fallback, got %q", got)
+		}
+	})
+
+	t.Run("returns prefixed env var when set", func(t *testing.T) {
+		t.Setenv(envPrefix+"SOME_KEY", "from-env")
+		if got := envOrDefault("SOME_KEY", "fallback"); got != "from-env" {
+			t.Errorf("expected from-env, got %q", got)
+		}
+	})
+}
+
+func TestEnvIntOrDefault(t *testing.T) {
+	t.Setenv(envPrefix+"SOME_INT", "")
+	if got := envIntOrDefault("SOME_INT", 4); got != 4 {
+		t.Errorf("expected fallback, got %d", got)
+	}
+
+	t.Setenv(envPrefix+"SOME_INT", "8")
+	if got := envIntOrDefault("SOME_INT", 4); got != 8 {
+		t.Errorf("expected 8, got %d", got)
+	}
+
+	t.Setenv(envPrefix+"SOME_INT", "many")
+	if got := envIntOrDefault("SOME_INT", 4); got != 4 {
+		t.Errorf("expected fallback for invalid value, got %d", got)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "success", err: nil, expected: 0},
+		{name: "generic error", err: errors.New("failed to discover project"), expected: exitError},
+		{name: "build failure", err: errors.Join(errBuildFailed, errors.New("build failed for app:1")), expected: exitBuildFailed},
+		{name: "test failure", err: errors.Join(errTestFailed, errors.New("app:1 (linux/amd64): FAIL")), expected: exitTestFailed},
+		{name: "sbom failure", err: errors.Join(errSBOMFailed, errors.New("app:1 (linux/amd64): boom")), expected: exitSBOMFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exitCode(tc.err); got != tc.expected {
+				t.Errorf("expected exit code %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestGlobalOptions_FailurePolicy(t *testing.T) {
+	if policy, err := (&globalOptions{FailurePolicy: "skip-dependents"}).failurePolicy(); err != nil || policy != scheduler.SkipDependents {
+		t.Errorf("expected skip-dependents, got %q (%v)", policy, err)
+	}
+	if _, err := (&globalOptions{FailurePolicy: "ignore"}).failurePolicy(); err == nil {
+		t.Error("expected error for unsupported policy")
+	}
+}
+
+func TestGlobalOptions_Paths(t *testing.T) {
+	t.Run("defaults relative to project root", func(t *testing.T) {
+		opts := &globalOptions{ProjectRoot: "example"}
+		project := &model.ContainerHiveProject{RootDir: "/abs/example", Config: &model.HiveProjectConfig{}}
+		if got := opts.distPath(project); got != filepath.Join("example", "dist") {
+			t.Errorf("unexpected dist path %q", got)
+		}
+		if got := opts.reportPath(project); got != filepath.Join("example", "reports") {
+			t.Errorf("unexpected report path %q", got)
+		}
+	})
+
+	t.Run("project config is resolved against project root", func(t *testing.T) {
+		opts := &globalOptions{ProjectRoot: "example"}
+		project := &model.ContainerHiveProject{
+			RootDir: "/abs/example",
+			Config:  &model.HiveProjectConfig{DistDir: "out/dist", ReportDir: "/var/reports"},
+		}
+		if got := opts.distPath(project); got != filepath.Join("/abs/example", "out", "dist") {
+			t.Errorf("unexpected dist path %q", got)
+		}
+		if got := opts.reportPath(project); got != "/var/reports" {
+			t.Errorf("unexpected report path %q", got)
+		}
+	})
+
+	t.Run("explicit paths take precedence", func(t *testing.T) {
+		opts := &globalOptions{ProjectRoot: "example", DistDir: "/tmp/dist", ReportDir: "/tmp/reports"}
+		project := &model.ContainerHiveProject{
+			RootDir: "/abs/example",
+			Config:  &model.HiveProjectConfig{DistDir: "out/dist", ReportDir: "out/reports"},
+		}
+		if got := opts.distPath(project); got != "/tmp/dist" {
+			t.Errorf("unexpected dist path %q", got)
+		}
+		if got := opts.reportPath(project); got != "/tmp/reports" {
+			t.Errorf("unexpected report path %q", got)
+		}
+	})
+}
+
+func TestBuildOptions_BuildkitAddr(t *testing.T) {
+	project := &model.ContainerHiveProject{Config: &model.HiveProjectConfig{}}
+	if got := (&buildOptions{}).buildkitAddr(project); got != defaultBuildkitAddr {
+		t.Errorf("expected default address, got %q", got)
+	}
+
+	project.Config.Buildkit.Address = "tcp://from-config:1234"
+	if got := (&buildOptions{}).buildkitAddr(project); got != "tcp://from-config:1234" {
+		t.Errorf("expected address from config, got %q", got)
+	}
+
+	if got := (&buildOptions{BuildkitAddr: "tcp://from-flag:1234"}).buildkitAddr(project); got != "tcp://from-flag:1234" {
+		t.Errorf("expected address from flag, got %q", got)
+	}
+}
+
+func TestPublishOptions_Registry(t *testing.T) {
+	project := &model.ContainerHiveProject{Config: &model.HiveProjectConfig{}}
+	if got := (&publishOptions{}).registry(project); got != "" {
+		t.Errorf("expected no registry, got %q", got)
+	}
+
+	project.Config.Registries.Publish = "registry.example.com/library"
+	if got := (&publishOptions{}).registry(project); got != "registry.example.com/library" {
+		t.Errorf("expected registry from config, got %q", got)
+	}
+
+	if got := (&publishOptions{Registry: "ghcr.io/acme"}).registry(project); got != "ghcr.io/acme" {
+		t.Errorf("expected registry from flag, got %q", got)
+	}
+}
+
+func TestDiscoverCommand(t *testing.T) {
+	out := executeCommand(t, "discover", "--project", "../../pkg/testdata/simple-project")
+
+	for _, expected := range []string{
+		"dotnet/8",
+		"8.0.100,8.0.200,8.0.300",
+		"node",
+		"python",
+		"3.13.7",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestRenderCommand(t *testing.T) {
+	dist := filepath.Join(t.TempDir(), "dist")
+	executeCommand(t, "render", "--project", "../../pkg/testdata/minimal-project", "--dist", dist)
+
+	targets := builtTargets(mustDiscover(t, "../../pkg/testdata/minimal-project"), dist)
+	if len(targets) != 0 {
+		t.Errorf("expected no built targets after render, got %d", len(targets))
+	}
+}
+
+func TestGraphCommand(t *testing.T) {
+	dist := filepath.Join(t.TempDir(), "dist")
+	out := executeCommand(t, "graph", "--project", "../../pkg/testdata/dependency-project", "--dist", dist)
+
+	expected := "ubuntu:22.04\npython:3.13 -> ubuntu:22.04\n"
+	if out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+
+	t.Run("levels", func(t *testing.T) {
+		out := executeCommand(t, "graph", "--project", "../../pkg/testdata/dependency-project", "--dist", dist, "--levels")
+		expected := "Level 0:\n  ubuntu:22.04\nLevel 1:\n  python:3.13 -> ubuntu:22.04\n"
+		if out != expected {
+			t.Errorf("expected %q, got %q", expected, out)
+		}
+	})
+
+	t.Run("mermaid", func(t *testing.T) {
+		out := executeCommand(t, "graph", "--project", "../../pkg/testdata/dependency-project", "--dist", dist, "--format", "mermaid")
+		if !strings.Contains(out, "n0 -->|reference, depends_on| n1") {
+			t.Errorf("expected reference edge in mermaid output, got:\n%s", out)
+		}
+	})
+
+	t.Run("keeps build output", func(t *testing.T) {
+		dist := filepath.Join(t.TempDir(), "dist")
+		built := filepath.Join(dist, "ubuntu", "22.04", "image.tar")
+		if err := os.MkdirAll(filepath.Dir(built), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(built, []byte("image"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		executeCommand(t, "graph", "--project", "../../pkg/testdata/dependency-project", "--dist", dist)
+
+		if _, err := os.Stat(built); err != nil {
+			t.Errorf("expected %s to survive graph, got %v", built, err)
+		}
+	})
+
+	t.Run("rejects unknown format", func(t *testing.T) {
+		root := newRootCommand()
+		root.SetOut(&bytes.Buffer{})
+		root.SetErr(&bytes.Buffer{})
+		root.SetArgs([]string{"graph", "--project", "../../pkg/testdata/dependency-project", "--format", "svg"})
+		if err := root.ExecuteContext(t.Context()); err == nil || !strings.Contains(err.Error(), "svg") {
+			t.Errorf("expected unsupported format error, got %v", err)
+		}
+	})
+}
+
+func TestPlanCommand(t *testing.T) {
+	out := executeCommand(t, "plan", "--project", "../../pkg/testdata/dependency-project")
+
+	for _, expected := range []string{
+		"1. ubuntu:22.04\n",
+		"2. python:3.13\n",
+		"   bases         ubuntu:22.04\n",
+		"   staging push  yes\n",
+		"   tests         skipped, no test definitions\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	t.Run("json", func(t *testing.T) {
+		out := executeCommand(t, "plan", "--project", "../../pkg/testdata/dependency-project", "--format", "json")
+		var steps []planStep
+		if err := json.Unmarshal([]byte(out), &steps); err != nil {
+			t.Fatalf("invalid json: %v\n%s", err, out)
+		}
+		var targets []string
+		for _, step := range steps {
+			targets = append(targets, step.Target)
+		}
+		if diff := cmp.Diff([]string{"ubuntu:22.04", "python:3.13"}, targets); diff != "" {
+			t.Errorf("unexpected plan order (-want +got):\n%s", diff)
+		}
+		if !steps[0].StagingPush || steps[1].StagingPush {
+			t.Errorf("expected only ubuntu:22.04 to be pushed to the staging registry")
+		}
+		if diff := cmp.Diff([]string{"ubuntu:22.04"}, steps[1].Bases); diff != "" {
+			t.Errorf("unexpected bases (-want +got):\n%s", diff)
+		}
+		if steps[0].SourceDateEpoch <= 0 {
+			t.Errorf("expected source date epoch to be set, got %d", steps[0].SourceDateEpoch)
+		}
+	})
+}
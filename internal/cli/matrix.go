@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+)
+
+var matrixGranularities = []string{"image", "tag"}
+
+type matrixOptions struct {
+	Per       string
+	Stage     int
+	Selection selectionOptions
+}
+
+// matrixEntry is a single CI job building an image or a tag or variant.
+type matrixEntry struct {
+	Name string `json:"name"`
+	// Selector is the value to pass to ch build --only
+	Selector string `json:"selector"`
+	// Command builds only the targets of the entry, bases of earlier stages are pulled from the staging registry
+	Command string `json:"command"`
+	// Needs are the names of the entries that have to be built before
+	Needs []string `json:"needs"`
+}
+
+// matrixStage contains all entries that can be built in parallel once the previous stage finished.
+// Include has the shape of a GitHub Actions matrix, for GitLab it can be rendered into parallel:matrix.
+type matrixStage struct {
+	Name    string        `json:"name"`
+	Needs   []string      `json:"needs"`
+	Include []matrixEntry `json:"include"`
+}
+
+type ciMatrix struct {
+	Stages []matrixStage `json:"stages"`
+}
+
+func newCIMatrixCommand(opts *globalOptions) *cobra.Command {
+	matrixOpts := &matrixOptions{}
+	cmd := &cobra.Command{
+		Use:   "ci-matrix",
+		Short: "Print a JSON CI job matrix with one entry per image or tag, grouped into stages in build order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !slices.Contains(matrixGranularities, matrixOpts.Per) {
+				return fmt.Errorf("unsupported matrix granularity %q, expected one of %s", matrixOpts.Per, strings.Join(matrixGranularities, ", "))
+			}
+			if err := matrixOpts.Selection.validate(); err != nil {
+				return err
+			}
+
+			project, err := discoverProject(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+
+			distPath, cleanup, err := renderTemporary(cmd.Context(), project)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			graph, err := resolveDependencyGraph(distPath, project)
+			if err != nil {
+				return err
+			}
+
+			targets, err := matrixOpts.Selection.selectTargets(collectTargets(project, distPath, imageNames(project)), graph.Graph, true)
+			if err != nil {
+				return err
+			}
+
+			matrix, err := buildCIMatrix(graph.Graph, targets, matrixOpts.Per == "image", matrixOpts.Selection.active())
+			if err != nil {
+				return err
+			}
+			return writeCIMatrix(cmd.OutOrStdout(), matrix, matrixOpts.Stage)
+		},
+	}
+	cmd.Flags().StringVar(&matrixOpts.Per, "per", envOrDefault("CI_MATRIX_PER", "image"), "Create one entry per image or per tag and variant, one of "+strings.Join(matrixGranularities, ", ")+" [$"+envPrefix+"CI_MATRIX_PER]")
+	cmd.Flags().IntVar(&matrixOpts.Stage, "stage", -1, "Only print the include list of the stage with this index, e.g. for fromJSON in GitHub Actions")
+	matrixOpts.Selection.addFlags(cmd)
+	return cmd
+}
+
+// buildCIMatrix groups the targets into stages derived from the build levels of the graph.
+// Per image, an image depends on another image if any of its tags or variants does. The selector of an image
+// matches all of its tags and variants, unless the targets are a selection, then it lists the selected ones.
+func buildCIMatrix(graph *dependency.Graph, targets []*buildTarget, perImage, selected bool) (*ciMatrix, error) {
+	entryOf := make(map[string]string, len(targets))
+	keysOf := make(map[string][]string)
+	for _, target := range targets {
+		name := target.ImageTag()
+		if perImage {
+			name = target.Image.Name
+		}
+		entryOf[target.ImageTag()] = name
+		keysOf[name] = append(keysOf[name], target.ImageTag())
+	}
+
+	entries := dependency.NewGraph()
+	for _, target := range targets {
+		from := entryOf[target.ImageTag()]
+		entries.AddImage(from)
+		for _, dep := range graph.Dependencies(target.ImageTag()) {
+			// Variants depending on a tag of the same image are built within the same entry
+			if to, ok := entryOf[dep]; ok && to != from {
+				entries.AddDependency(from, to)
+			}
+		}
+	}
+
+	levels, err := entries.Levels()
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to derive CI stages"), err)
+	}
+
+	matrix := &ciMatrix{Stages: []matrixStage{}}
+	for i, level := range levels {
+		stage := matrixStage{Name: fmt.Sprintf("stage-%d", i), Needs: []string{}, Include: []matrixEntry{}}
+		if i > 0 {
+			stage.Needs = append(stage.Needs, fmt.Sprintf("stage-%d", i-1))
+		}
+		for _, name := range level {
+			selector := name
+			if perImage && selected {
+				selector = strings.Join(keysOf[name], ",")
+			}
+			needs := slices.Compact(slices.Sorted(slices.Values(entries.Dependencies(name))))
+			stage.Include = append(stage.Include, matrixEntry{
+				Name:     name,
+				Selector: selector,
+				Command:  "ch build --no-deps --only " + selector,
+				Needs:    append([]string{}, needs...),
+			})
+		}
+		matrix.Stages = append(matrix.Stages, stage)
+	}
+	return matrix, nil
+}
+
+// writeCIMatrix writes the whole matrix or only the include list of a stage if stage is not negative.
+// Stages beyond the last one result in an empty include list.
+func writeCIMatrix(out io.Writer, matrix *ciMatrix, stage int) error {
+	encoder := json.NewEncoder(out)
+	if stage < 0 {
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(matrix)
+	}
+
+	include := []matrixEntry{}
+	if stage < len(matrix.Stages) {
+		include = matrix.Stages[stage].Include
+	}
+	return encoder.Encode(map[string][]matrixEntry{"include": include})
+}
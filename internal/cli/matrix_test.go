@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildCIMatrix(t *testing.T) {
+	targets, graph := selectionFixture()
+
+	t.Run("per image", func(t *testing.T) {
+		matrix, err := buildCIMatrix(graph, targets, true, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := &ciMatrix{Stages: []matrixStage{
+			{Name: "stage-0", Needs: []string{}, Include: []matrixEntry{
+				{Name: "ubuntu", Selector: "ubuntu", Command: "ch build --no-deps --only ubuntu", Needs: []string{}},
+			}},
+			{Name: "stage-1", Needs: []string{"stage-0"}, Include: []matrixEntry{
+				{Name: "python", Selector: "python", Command: "ch build --no-deps --only python", Needs: []string{"ubuntu"}},
+			}},
+			{Name: "stage-2", Needs: []string{"stage-1"}, Include: []matrixEntry{
+				{Name: "app", Selector: "app", Command: "ch build --no-deps --only app", Needs: []string{"python"}},
+			}},
+		}}
+		if diff := cmp.Diff(expected, matrix); diff != "" {
+			t.Errorf("buildCIMatrix() mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("per tag", func(t *testing.T) {
+		matrix, err := buildCIMatrix(graph, targets, false, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := &ciMatrix{Stages: []matrixStage{
+			{Name: "stage-0", Needs: []string{}, Include: []matrixEntry{
+				{Name: "ubuntu:22.04", Selector: "ubuntu:22.04", Command: "ch build --no-deps --only ubuntu:22.04", Needs: []string{}},
+			}},
+			{Name: "stage-1", Needs: []string{"stage-0"}, Include: []matrixEntry{
+				{Name: "python:3.12", Selector: "python:3.12", Command: "ch build --no-deps --only python:3.12", Needs: []string{"ubuntu:22.04"}},
+				{Name: "python:3.13", Selector: "python:3.13", Command: "ch build --no-deps --only python:3.13", Needs: []string{"ubuntu:22.04"}},
+			}},
+			{Name: "stage-2", Needs: []string{"stage-1"}, Include: []matrixEntry{
+				{Name: "python:3.13-node", Selector: "python:3.13-node", Command: "ch build --no-deps --only python:3.13-node", Needs: []string{"python:3.13"}},
+			}},
+			{Name: "stage-3", Needs: []string{"stage-2"}, Include: []matrixEntry{
+				{Name: "app:1.0", Selector: "app:1.0", Command: "ch build --no-deps --only app:1.0", Needs: []string{"python:3.13-node"}},
+			}},
+		}}
+		if diff := cmp.Diff(expected, matrix); diff != "" {
+			t.Errorf("buildCIMatrix() mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("selection lists the selected tags per image", func(t *testing.T) {
+		selection := selectionOptions{Only: []string{"python:3.13-node"}}
+		selected, err := selection.selectTargets(targets, graph, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matrix, err := buildCIMatrix(graph, selected, true, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := matrix.Stages[1].Include[0].Selector; got != "python:3.13,python:3.13-node" {
+			t.Errorf("unexpected selector %q", got)
+		}
+	})
+}
+
+func TestBuildCIMatrix_SelectorRoundTrip(t *testing.T) {
+	targets, graph := selectionFixture()
+	selection := selectionOptions{Only: []string{"python"}}
+	selected, err := selection.selectTargets(targets, graph, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		targets  []*buildTarget
+		perImage bool
+		selected bool
+	}{
+		{name: "per image", targets: targets, perImage: true},
+		{name: "per tag", targets: targets},
+		{name: "per image with selection", targets: selected, perImage: true, selected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matrix, err := buildCIMatrix(graph, tc.targets, tc.perImage, tc.selected)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, stage := range matrix.Stages {
+				for _, entry := range stage.Include {
+					var expected []string
+					for _, target := range tc.targets {
+						if target.ImageTag() == entry.Name || (tc.perImage && target.Image.Name == entry.Name) {
+							expected = append(expected, target.ImageTag())
+						}
+					}
+
+					// ch build --no-deps does not add the dependencies of the selected targets
+					only := selectionOptions{Only: splitList(entry.Selector)}
+					got, err := only.selectTargets(targets, graph, false)
+					if err != nil {
+						t.Fatalf("unexpected error for %s: %v", entry.Name, err)
+					}
+					if diff := cmp.Diff(expected, targetKeys(got)); diff != "" {
+						t.Errorf("selector of %s mismatch (-expected +got):\n%s", entry.Name, diff)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWriteCIMatrix_Stage(t *testing.T) {
+	matrix := &ciMatrix{Stages: []matrixStage{
+		{Name: "stage-0", Include: []matrixEntry{{Name: "ubuntu", Selector: "ubuntu", Command: "ch build --no-deps --only ubuntu", Needs: []string{}}}},
+	}}
+
+	var out bytes.Buffer
+	if err := writeCIMatrix(&out, matrix, 0); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"include":[{"name":"ubuntu","selector":"ubuntu","command":"ch build --no-deps --only ubuntu","needs":[]}]}` + "\n"
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+
+	out.Reset()
+	if err := writeCIMatrix(&out, matrix, 3); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != `{"include":[]}`+"\n" {
+		t.Errorf("expected empty include list for missing stage, got %s", out.String())
+	}
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/timo-reymann/ContainerHive/internal/buildconfig_resolver"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+	"github.com/timo-reymann/ContainerHive/internal/image_metadata"
+)
+
+// imageMetadata returns the labels and annotations of the target.
+// The generated org.opencontainers.image.* values are overridden by the configured ones.
+func (b *imageBuilder) imageMetadata(target *buildTarget, values *buildconfig_resolver.ResolvedBuildValues) (map[string]string, map[string]string, error) {
+	info := image_metadata.Info{
+		Title:   target.Image.Name,
+		Version: target.TagName(),
+		Created: b.sourceDateEpochs[target.Image.Identifier],
+		Source:  b.source,
+	}
+
+	base, err := dependency.ScanDockerfileForBaseRef(filepath.Join(target.DistDir, "Dockerfile"), values.ToBuildArgs())
+	if err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("failed to determine base image of %s", target.ImageTag()), err)
+	}
+	if base != nil {
+		info.BaseName = base.Key()
+		if b.publishRegistry != "" {
+			info.BaseName = b.publishRegistry + "/" + base.Key()
+		}
+		// Dependencies are built before, so the state contains the digest of the base from this run
+		entry, _ := b.state.Get(base.Key())
+		info.BaseDigest = entry.Digest
+	}
+
+	generated := image_metadata.Generate(info)
+	return image_metadata.Merge(generated, values.Labels), image_metadata.Merge(generated, values.Annotations), nil
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/internal/fingerprint"
+	"github.com/timo-reymann/ContainerHive/internal/image_metadata"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+	"github.com/timo-reymann/ContainerHive/pkg/rendering"
+)
+
+func TestImageBuilder_ImageMetadata(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/dependency-project")
+	dist := filepath.Join(t.TempDir(), "dist")
+	if err := rendering.RenderProject(t.Context(), project, dist); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := make(map[string]*buildTarget)
+	for _, target := range collectTargets(project, dist, imageNames(project)) {
+		targets[target.ImageTag()] = target
+	}
+
+	state := fingerprint.NewState()
+	state.Set("ubuntu:22.04", fingerprint.Entry{Fingerprint: "sha256:ffff", Digest: "sha256:aaaa"})
+	builder := &imageBuilder{
+		state:            state,
+		sourceDateEpochs: map[string]int64{"python": 1700000000, "ubuntu": 1600000000},
+		source:           image_metadata.Source{Revision: "0123abcd", URL: "https://github.com/acme/images"},
+		publishRegistry:  "registry.example.com/library",
+	}
+
+	python := targets["python:3.13"]
+	values, err := python.ResolveBuildValues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values.Labels = model.Labels{image_metadata.KeyTitle: "Python", "com.example.team": "platform"}
+	values.Annotations = model.Annotations{"com.example.docs": "https://docs.example.com"}
+
+	labels, annotations, err := builder.imageMetadata(python, values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	generated := map[string]string{
+		image_metadata.KeyTitle:      "python",
+		image_metadata.KeyVersion:    "3.13",
+		image_metadata.KeyCreated:    "2023-11-14T22:13:20Z",
+		image_metadata.KeyRevision:   "0123abcd",
+		image_metadata.KeySource:     "https://github.com/acme/images",
+		image_metadata.KeyBaseName:   "registry.example.com/library/ubuntu:22.04",
+		image_metadata.KeyBaseDigest: "sha256:aaaa",
+	}
+	expectedLabels := image_metadata.Merge(generated, map[string]string{image_metadata.KeyTitle: "Python", "com.example.team": "platform"})
+	if diff := cmp.Diff(expectedLabels, labels); diff != "" {
+		t.Errorf("labels mismatch (-expected +got):\n%s", diff)
+	}
+	expectedAnnotations := image_metadata.Merge(generated, map[string]string{"com.example.docs": "https://docs.example.com"})
+	if diff := cmp.Diff(expectedAnnotations, annotations); diff != "" {
+		t.Errorf("annotations mismatch (-expected +got):\n%s", diff)
+	}
+
+	t.Run("without base", func(t *testing.T) {
+		ubuntu := targets["ubuntu:22.04"]
+		values, err := ubuntu.ResolveBuildValues()
+		if err != nil {
+			t.Fatal(err)
+		}
+		labels, _, err := builder.imageMetadata(ubuntu, values)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := labels[image_metadata.KeyBaseName]; ok {
+			t.Errorf("expected no base name, got %q", labels[image_metadata.KeyBaseName])
+		}
+	})
+}
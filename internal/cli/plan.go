@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/timo-reymann/ContainerHive/internal/source_date"
+)
+
+var planFormats = []string{"text", "json"}
+
+type planOptions struct {
+	Format string
+}
+
+// planStep is everything that happens for a tag or variant during build, test, sbom and publish.
+type planStep struct {
+	Target    string            `json:"target"`
+	Image     string            `json:"image"`
+	Tag       string            `json:"tag"`
+	Variant   string            `json:"variant,omitempty"`
+	Platforms []string          `json:"platforms"`
+	BuildArgs map[string]string `json:"build_args"`
+	// SourceDateEpoch is the configured value or the time of the last commit touching the image
+	SourceDateEpoch int64 `json:"source_date_epoch"`
+	// Secrets only contains the names, values are never part of the plan
+	Secrets []string `json:"secrets"`
+	// Bases are the targets referenced via __hive__/
+	Bases     []string `json:"bases"`
+	DependsOn []string `json:"depends_on"`
+	// StagingPush is set if other targets reference the target, so it is staged for their builds
+	StagingPush bool     `json:"staging_push"`
+	SBOMs       []string `json:"sbom_platforms"`
+	Tests       []string `json:"test_definitions"`
+	Aliases     []string `json:"aliases"`
+}
+
+func newPlanCommand(opts *globalOptions) *cobra.Command {
+	planOpts := &planOptions{}
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Print what build, test, sbom and publish would do for all tags and variants without building anything",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !slices.Contains(planFormats, planOpts.Format) {
+				return fmt.Errorf("unsupported plan format %q, expected one of %s", planOpts.Format, strings.Join(planFormats, ", "))
+			}
+
+			project, err := discoverProject(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+
+			distPath, cleanup, err := renderTemporary(cmd.Context(), project)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			graph, err := resolveDependencyGraph(distPath, project)
+			if err != nil {
+				return err
+			}
+
+			sourceDateEpochs, err := source_date.Resolve(project)
+			if err != nil {
+				return errors.Join(errors.New("failed to derive SOURCE_DATE_EPOCH from git history"), err)
+			}
+
+			steps, err := buildPlan(graph, collectTargets(project, distPath, imageNames(project)), sourceDateEpochs)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if planOpts.Format == "json" {
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(steps)
+			}
+			return writePlanText(out, steps)
+		},
+	}
+	cmd.Flags().StringVar(&planOpts.Format, "format", envOrDefault("PLAN_FORMAT", "text"), "Output format, one of "+strings.Join(planFormats, ", ")+" [$"+envPrefix+"PLAN_FORMAT]")
+	return cmd
+}
+
+// buildPlan returns the plan steps of the targets in build order.
+func buildPlan(graph *projectGraph, targets []*buildTarget, sourceDateEpochs map[string]int64) ([]planStep, error) {
+	targetsByKey := make(map[string]*buildTarget, len(targets))
+	for _, target := range targets {
+		targetsByKey[target.ImageTag()] = target
+	}
+
+	steps := make([]planStep, 0, len(graph.BuildOrder))
+	for _, key := range graph.BuildOrder {
+		target, ok := targetsByKey[key]
+		if !ok {
+			continue
+		}
+
+		bases := slices.Sorted(slices.Values(graph.Scanned.Dependencies(key)))
+		dependsOn := []string{}
+		for _, dep := range slices.Sorted(slices.Values(graph.Graph.Dependencies(key))) {
+			if !slices.Contains(bases, dep) && !slices.Contains(dependsOn, dep) {
+				dependsOn = append(dependsOn, dep)
+			}
+		}
+
+		tests := []string{}
+		for _, path := range target.TestDefinitions() {
+			tests = append(tests, filepath.Base(path))
+		}
+
+		step := planStep{
+			Target:          key,
+			Image:           target.Image.Name,
+			Tag:             target.TagName(),
+			Platforms:       target.Platforms(),
+			BuildArgs:       target.BuildArgs(),
+			SourceDateEpoch: sourceDateEpochs[target.Image.Identifier],
+			Secrets:         target.SecretNames(),
+			Bases:           slices.Compact(bases),
+			DependsOn:       dependsOn,
+			StagingPush:     len(graph.Scanned.Dependents(key)) > 0,
+			SBOMs:           target.Platforms(),
+			Tests:           tests,
+			Aliases:         append([]string{}, target.Aliases...),
+		}
+		if target.Variant != nil {
+			step.Variant = target.Variant.Name
+		}
+		if step.Secrets == nil {
+			step.Secrets = []string{}
+		}
+		if step.Bases == nil {
+			step.Bases = []string{}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// writePlanText prints a table per step with everything that happens for the target.
+func writePlanText(out io.Writer, steps []planStep) error {
+	for i, step := range steps {
+		if i > 0 {
+			_, _ = fmt.Fprintln(out)
+		}
+		_, _ = fmt.Fprintf(out, "%d. %s\n", i+1, step.Target)
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		row := func(name string, values []string) {
+			value := "-"
+			if len(values) > 0 {
+				value = strings.Join(values, ", ")
+			}
+			_, _ = fmt.Fprintf(w, "   %s\t%s\n", name, value)
+		}
+
+		if step.Variant != "" {
+			row("variant", []string{step.Variant})
+		}
+		row("platforms", step.Platforms)
+		var buildArgs []string
+		for _, name := range slices.Sorted(maps.Keys(step.BuildArgs)) {
+			buildArgs = append(buildArgs, name+"="+step.BuildArgs[name])
+		}
+		row("build args", buildArgs)
+		row("source date", []string{strconv.FormatInt(step.SourceDateEpoch, 10)})
+		row("secrets", step.Secrets)
+		row("bases", step.Bases)
+		row("depends on", step.DependsOn)
+		if step.StagingPush {
+			row("staging push", []string{"yes"})
+		} else {
+			row("staging push", nil)
+		}
+		row("sbom", step.SBOMs)
+		if len(step.Tests) > 0 {
+			row("tests", step.Tests)
+		} else {
+			row("tests", []string{"skipped, no test definitions"})
+		}
+		row("aliases", step.Aliases)
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
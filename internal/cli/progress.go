@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/progress/progressui"
+)
+
+var progressModes = []string{
+	string(progressui.AutoMode),
+	string(progressui.TtyMode),
+	string(progressui.PlainMode),
+	string(progressui.RawJSONMode),
+	string(progressui.QuietMode),
+}
+
+// parseProgressMode validates the name of a progress mode.
+func parseProgressMode(name string) (progressui.DisplayMode, error) {
+	if !slices.Contains(progressModes, name) {
+		return "", fmt.Errorf("unsupported progress mode %q, expected one of %s", name, strings.Join(progressModes, ", "))
+	}
+	return progressui.DisplayMode(name), nil
+}
+
+// progressOutput creates the buildkit status handlers for all builds of a run.
+// Parallel builds can not share a TTY display, so they fall back to plain mode. Their lines are prefixed with
+// the target and written as a whole, so output of different images does not interleave within a line.
+// Besides the display, the steps and log output of each build are written to a log file in the report directory.
+type progressOutput struct {
+	out      io.Writer
+	mode     progressui.DisplayMode
+	parallel bool
+	// reportDir receives the log and trace files of the builds, nothing is written if it is empty
+	reportDir string
+	trace     bool
+	mu        sync.Mutex
+	// created holds the log and trace files written during this run
+	created map[string]bool
+}
+
+func newProgressOutput(out io.Writer, mode progressui.DisplayMode, parallel bool, reportDir string, trace bool) *progressOutput {
+	if parallel && (mode == progressui.AutoMode || mode == progressui.TtyMode) {
+		mode = progressui.PlainMode
+	}
+	return &progressOutput{
+		out:       out,
+		mode:      mode,
+		parallel:  parallel,
+		reportDir: reportDir,
+		trace:     trace,
+		created:   make(map[string]bool),
+	}
+}
+
+// logPath returns the build log file of the target or an empty string if logs are disabled.
+func (p *progressOutput) logPath(target *buildTarget) string {
+	if p.reportDir == "" {
+		return ""
+	}
+	return filepath.Join(p.reportDir, fmt.Sprintf("%s-%s.log", target.Image.Name, target.TagName()))
+}
+
+// tracePath returns the trace file of the target or an empty string if traces are disabled.
+func (p *progressOutput) tracePath(target *buildTarget) string {
+	if p.reportDir == "" || !p.trace {
+		return ""
+	}
+	return filepath.Join(p.reportDir, fmt.Sprintf("%s-%s.trace.json", target.Image.Name, target.TagName()))
+}
+
+// files returns the log and trace file written for the target during this run, empty if the target was not built.
+func (p *progressOutput) files(target *buildTarget) (logPath string, tracePath string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if path := p.logPath(target); p.created[path] {
+		logPath = path
+	}
+	if path := p.tracePath(target); p.created[path] {
+		tracePath = path
+	}
+	return logPath, tracePath
+}
+
+func (p *progressOutput) create(path string) (*os.File, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to create %s", path), err)
+	}
+	p.mu.Lock()
+	p.created[path] = true
+	p.mu.Unlock()
+	return f, nil
+}
+
+// handler returns the status handler displaying the build progress of the target.
+func (p *progressOutput) handler(target *buildTarget) func(chan *client.SolveStatus) error {
+	return func(ch chan *client.SolveStatus) error {
+		var out io.Writer = p.out
+		if p.parallel {
+			prefix := ""
+			if p.mode == progressui.PlainMode {
+				prefix = "[" + target.ImageTag() + "] "
+			}
+			lw := &lineWriter{out: p.out, mu: &p.mu, prefix: prefix}
+			defer lw.Flush()
+			out = lw
+		}
+
+		d, err := progressui.NewDisplay(out, p.mode)
+		if err != nil {
+			d, _ = progressui.NewDisplay(out, progressui.PlainMode)
+		}
+
+		var writers []statusWriter
+		var closers []func() error
+		var errs []error
+		if path := p.logPath(target); path != "" {
+			f, err := p.create(path)
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				defer f.Close()
+				log := newBuildLog(f)
+				writers = append(writers, log)
+				closers = append(closers, log.Close)
+			}
+		}
+		if path := p.tracePath(target); path != "" {
+			f, err := p.create(path)
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				defer f.Close()
+				writers = append(writers, &traceWriter{encoder: json.NewEncoder(f)})
+			}
+		}
+
+		if len(writers) == 0 {
+			_, err = d.UpdateFrom(context.TODO(), ch)
+			return errors.Join(append(errs, err)...)
+		}
+
+		displayed := make(chan *client.SolveStatus)
+		displayErr := make(chan error, 1)
+		go func() {
+			_, err := d.UpdateFrom(context.TODO(), displayed)
+			displayErr <- err
+		}()
+
+		errs = append(errs, teeStatus(ch, displayed, writers...))
+		errs = append(errs, <-displayErr)
+		for _, c := range closers {
+			errs = append(errs, c())
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// statusWriter persists the status stream of a build.
+type statusWriter interface {
+	WriteStatus(status *client.SolveStatus) error
+}
+
+// traceWriter writes every status as a JSON line.
+type traceWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *traceWriter) WriteStatus(status *client.SolveStatus) error {
+	return w.encoder.Encode(status)
+}
+
+// teeStatus passes every status to the writers and forwards it to out, which is closed at the end.
+// A writer that failed is not written to anymore, but the status channel is always drained.
+func teeStatus(in <-chan *client.SolveStatus, out chan<- *client.SolveStatus, writers ...statusWriter) error {
+	defer close(out)
+	failed := make([]bool, len(writers))
+	var errs []error
+	for status := range in {
+		for i, w := range writers {
+			if failed[i] {
+				continue
+			}
+			if err := w.WriteStatus(status); err != nil {
+				failed[i] = true
+				errs = append(errs, errors.Join(errors.New("failed to write build output"), err))
+			}
+		}
+		out <- status
+	}
+	return errors.Join(errs...)
+}
+
+// lineWriter writes complete lines with an optional prefix to a writer shared with other builds.
+type lineWriter struct {
+	out    io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush writes a remaining incomplete line.
+func (w *lineWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLine(append(w.buf, '\n'))
+	w.buf = nil
+	return err
+}
+
+func (w *lineWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.out.Write(append([]byte(w.prefix), line...))
+	return err
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/progress/progressui"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+func TestParseProgressMode(t *testing.T) {
+	for _, name := range progressModes {
+		mode, err := parseProgressMode(name)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", name, err)
+		}
+		if string(mode) != name {
+			t.Errorf("expected mode %s, got %s", name, mode)
+		}
+	}
+
+	if _, err := parseProgressMode("fancy"); err == nil {
+		t.Error("expected error for unsupported mode")
+	}
+}
+
+func TestNewProgressOutput_Mode(t *testing.T) {
+	testCases := []struct {
+		mode     progressui.DisplayMode
+		parallel bool
+		expected progressui.DisplayMode
+	}{
+		{mode: progressui.AutoMode, expected: progressui.AutoMode},
+		{mode: progressui.TtyMode, expected: progressui.TtyMode},
+		{mode: progressui.AutoMode, parallel: true, expected: progressui.PlainMode},
+		{mode: progressui.TtyMode, parallel: true, expected: progressui.PlainMode},
+		{mode: progressui.RawJSONMode, parallel: true, expected: progressui.RawJSONMode},
+		{mode: progressui.QuietMode, parallel: true, expected: progressui.QuietMode},
+	}
+
+	for _, tc := range testCases {
+		p := newProgressOutput(&bytes.Buffer{}, tc.mode, tc.parallel, "", false)
+		if p.mode != tc.expected {
+			t.Errorf("mode %s with parallel=%v: expected %s, got %s", tc.mode, tc.parallel, tc.expected, p.mode)
+		}
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	a := &lineWriter{out: &out, mu: &mu, prefix: "[a] "}
+	b := &lineWriter{out: &out, mu: &mu, prefix: "[b] "}
+
+	a.Write([]byte("first "))
+	b.Write([]byte("other\nsecond "))
+	a.Write([]byte("line\nrest"))
+	b.Write([]byte("line\n"))
+	a.Flush()
+	b.Flush()
+
+	expected := "[b] other\n[a] first line\n[b] second line\n[a] rest\n"
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestProgressOutput_Files(t *testing.T) {
+	reportDir := t.TempDir()
+	var out bytes.Buffer
+	p := newProgressOutput(&out, progressui.PlainMode, true, reportDir, true)
+	target := &buildTarget{
+		Image: &model.Image{Name: "nginx"},
+		Tag:   &model.Tag{Name: "1.27"},
+	}
+
+	ch := make(chan *client.SolveStatus)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.handler(target)(ch)
+	}()
+
+	started := time.Now()
+	statuses := []*client.SolveStatus{
+		{Vertexes: []*client.Vertex{{Digest: "sha256:a", Name: "[1/2] FROM docker.io/library/nginx", Started: &started, Completed: &started}}},
+		{Vertexes: []*client.Vertex{{Digest: "sha256:b", Name: "[2/2] RUN echo hello", Started: &started, Completed: &started}}},
+	}
+	for _, status := range statuses {
+		ch <- status
+	}
+	close(ch)
+	if err := <-errCh; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logPath, tracePath := p.files(target)
+	if logPath != filepath.Join(reportDir, "nginx-1.27.log") {
+		t.Errorf("unexpected log path %s", logPath)
+	}
+	if tracePath != filepath.Join(reportDir, "nginx-1.27.trace.json") {
+		t.Errorf("unexpected trace path %s", tracePath)
+	}
+	if _, err := os.Stat(logPath); err != nil {
+		t.Errorf("expected log file: %v", err)
+	}
+	f, err := os.Open(tracePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var status client.SolveStatus
+		if err := json.Unmarshal(scanner.Bytes(), &status); err != nil {
+			t.Fatalf("invalid trace line %q: %v", scanner.Text(), err)
+		}
+		for _, v := range status.Vertexes {
+			names = append(names, v.Name)
+		}
+	}
+	if diff := cmp.Diff([]string{"[1/2] FROM docker.io/library/nginx", "[2/2] RUN echo hello"}, names); diff != "" {
+		t.Errorf("unexpected trace (-want +got):\n%s", diff)
+	}
+
+	output := strings.TrimSpace(out.String())
+	if output == "" {
+		t.Fatal("expected progress output")
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "[nginx:1.27] ") {
+			t.Errorf("expected output line to be prefixed with the target, got %q", line)
+		}
+	}
+}
+
+func TestProgressOutput_FilesNotBuilt(t *testing.T) {
+	p := newProgressOutput(&bytes.Buffer{}, progressui.QuietMode, false, t.TempDir(), true)
+	target := &buildTarget{
+		Image: &model.Image{Name: "nginx"},
+		Tag:   &model.Tag{Name: "1.27"},
+	}
+
+	logPath, tracePath := p.files(target)
+	if logPath != "" || tracePath != "" {
+		t.Errorf("expected no files for a target that was not built, got %q and %q", logPath, tracePath)
+	}
+}
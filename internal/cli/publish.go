@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/timo-reymann/ContainerHive/internal/registry"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+type publishOptions struct {
+	Registry  string
+	Selection selectionOptions
+}
+
+// registry returns the registry to publish to from the flag or env var, then from the project config.
+func (p *publishOptions) registry(project *model.ContainerHiveProject) string {
+	if p.Registry != "" {
+		return p.Registry
+	}
+	return project.Config.Registries.Publish
+}
+
+func newPublishCommand(opts *globalOptions) *cobra.Command {
+	publishOpts := &publishOptions{}
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Push all built images in the dist directory with their floating tags to the publish registry",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			project, err := discoverProject(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+
+			publishRegistry := publishOpts.registry(project)
+			if publishRegistry == "" {
+				return errors.New("no publish registry configured, set registries.publish in the project config or pass --registry")
+			}
+
+			targets, err := selectBuiltTargets(project, opts.distPath(project), &publishOpts.Selection)
+			if err != nil {
+				return err
+			}
+			for _, target := range targets {
+				repository := publishRegistry + "/" + target.Image.Name
+				tags := target.PublishTags()
+				log.Printf("Publishing %s to %s with tag(s) %s ...", target.ImageTag(), repository, strings.Join(tags, ", "))
+				if err := registry.Publish(cmd.Context(), repository, tags, target.TarFile()); err != nil {
+					return errors.Join(fmt.Errorf("failed to publish %s", target.ImageTag()), err)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&publishOpts.Registry, "registry", envOrDefault("PUBLISH_REGISTRY", ""), "Registry to publish images to, defaults to registries.publish from the project config [$"+envPrefix+"PUBLISH_REGISTRY]")
+	publishOpts.Selection.addFlags(cmd)
+	return cmd
+}
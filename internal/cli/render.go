@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newRenderCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "render",
+		Short: "Render Dockerfiles, rootfs and tests of all images into the dist directory",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			project, err := discoverProject(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			return renderProject(cmd.Context(), opts, project)
+		},
+	}
+}
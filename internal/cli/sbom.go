@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/timo-reymann/ContainerHive/internal/report"
+	"github.com/timo-reymann/ContainerHive/internal/scheduler"
+	"github.com/timo-reymann/ContainerHive/internal/syft"
+)
+
+func newSBOMCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "sbom",
+		Short: "Generate SPDX SBOMs for all built images in the dist directory",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			policy, err := opts.failurePolicy()
+			if err != nil {
+				return err
+			}
+
+			project, err := discoverProject(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+
+			reportDir := opts.reportPath(project)
+			if err := os.MkdirAll(reportDir, 0755); err != nil {
+				return errors.Join(errors.New("failed to create report directory"), err)
+			}
+
+			reportFile := filepath.Join(reportDir, report.FileName)
+			buildReport, err := report.Load(reportFile)
+			if err != nil {
+				return err
+			}
+
+			sbomTool, err := syft.NewSBOMImageTool()
+			if err != nil {
+				return errors.Join(errors.New("failed to initialize SBOM tool"), err)
+			}
+
+			var failures []error
+		targets:
+			for _, target := range builtTargets(project, opts.distPath(project)) {
+				for _, platform := range target.Platforms() {
+					result := generateSBOM(cmd.Context(), sbomTool, target, platform)
+					buildReport.SetSBOM(target.reportTarget(), result)
+					if result.Status != report.StatusFailed {
+						continue
+					}
+					failures = append(failures, fmt.Errorf("%s (%s): %s", target.ImageTag(), platform, result.Error))
+					if policy == scheduler.FailFast {
+						break targets
+					}
+				}
+			}
+
+			if err := buildReport.Save(reportFile); err != nil {
+				return err
+			}
+			if len(failures) > 0 {
+				return errors.Join(append([]error{errSBOMFailed}, failures...)...)
+			}
+			return nil
+		},
+	}
+}
+
+// generateSBOM generates an SPDX SBOM for a platform of a built image tar and writes it alongside the tar.
+func generateSBOM(ctx context.Context, sbomTool *syft.SBOMImageTool, target *buildTarget, platform string) report.SBOMResult {
+	imageTag := target.ImageTag() + " (" + platform + ")"
+	tarFile := target.TarFile()
+	start := time.Now()
+	result := report.SBOMResult{Platform: platform, Status: report.StatusFailed}
+	failed := func(msg string, err error) report.SBOMResult {
+		log.Printf("%s for %s: %v", msg, imageTag, err)
+		result.Duration = time.Since(start).Seconds()
+		result.Error = err.Error()
+		return result
+	}
+
+	log.Printf("Generating SBOM for %s ...", imageTag)
+	sbomResult, err := sbomTool.GenerateSBOM(ctx, tarFile, platform)
+	if err != nil {
+		return failed("SBOM generation failed", err)
+	}
+	serialized, err := sbomTool.SerializeSBOM(sbomResult, "spdx-json")
+	if err != nil {
+		return failed("SBOM serialization failed", err)
+	}
+	sbomPath := tarFile + "." + platformFileSuffix(platform) + ".sbom.spdx.json"
+	if err := os.WriteFile(sbomPath, serialized, 0644); err != nil {
+		return failed("Failed to write SBOM", err)
+	}
+	log.Printf("SBOM written for %s -> %s (%d bytes)", imageTag, sbomPath, len(serialized))
+
+	result.Status = report.StatusSucceeded
+	result.Duration = time.Since(start).Seconds()
+	result.Path = sbomPath
+	result.Size = len(serialized)
+	return result
+}
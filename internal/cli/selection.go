@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+	"github.com/timo-reymann/ContainerHive/internal/report"
+	"github.com/timo-reymann/ContainerHive/internal/scheduler"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+// selectionOptions selects the tags and variants a command works on.
+// Patterns are globs matched against name:tag including the variant suffix, e.g. python:3.13* or *:*-node.
+// Patterns without a colon match the image name.
+type selectionOptions struct {
+	Only           []string
+	Exclude        []string
+	WithDependents bool
+}
+
+func (s *selectionOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&s.Only, "only", splitList(envOrDefault("ONLY", "")), "Only process tags and variants matching one of the globs over name:tag, e.g. python:3.13* [$"+envPrefix+"ONLY]")
+	cmd.Flags().StringSliceVar(&s.Exclude, "exclude", splitList(envOrDefault("EXCLUDE", "")), "Skip tags and variants matching one of the globs over name:tag [$"+envPrefix+"EXCLUDE]")
+	cmd.Flags().BoolVar(&s.WithDependents, "with-dependents", false, "Also process all tags and variants depending on the selected ones")
+}
+
+// splitList splits a comma-separated list, an empty string results in an empty list.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// active reports whether the selection restricts the targets in any way.
+func (s *selectionOptions) active() bool {
+	return len(s.Only) > 0 || len(s.Exclude) > 0 || s.WithDependents
+}
+
+// validate checks the syntax of all patterns.
+func (s *selectionOptions) validate() error {
+	for _, pattern := range append(append([]string{}, s.Only...), s.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Join(fmt.Errorf("invalid selector %q", pattern), err)
+		}
+	}
+	return nil
+}
+
+func matchesAny(patterns []string, target *buildTarget) bool {
+	for _, pattern := range patterns {
+		subject := target.ImageTag()
+		if !strings.Contains(pattern, ":") {
+			subject = target.Image.Name
+		}
+		if matched, _ := path.Match(pattern, subject); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the target is selected by --only and --exclude.
+func (s *selectionOptions) matches(target *buildTarget) bool {
+	if len(s.Only) > 0 && !matchesAny(s.Only, target) {
+		return false
+	}
+	return !matchesAny(s.Exclude, target)
+}
+
+// selectTargets returns the selected targets in their original order.
+// With --with-dependents the transitive dependents of the selected targets are added unless they are excluded.
+// If withDependencies is set, the transitive dependencies are added as well, even if they are excluded,
+// because they are required to build the selected targets. graph is only used for these expansions.
+func (s *selectionOptions) selectTargets(targets []*buildTarget, graph *dependency.Graph, withDependencies bool) ([]*buildTarget, error) {
+	if !s.active() {
+		return targets, nil
+	}
+
+	targetsByKey := make(map[string]*buildTarget, len(targets))
+	selected := make(map[string]bool)
+	var keys []string
+	for _, target := range targets {
+		targetsByKey[target.ImageTag()] = target
+		if s.matches(target) {
+			selected[target.ImageTag()] = true
+			keys = append(keys, target.ImageTag())
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no tags or variants match the selection")
+	}
+
+	if s.WithDependents {
+		for _, key := range graph.TransitiveDependents(keys...) {
+			if target, ok := targetsByKey[key]; ok && !matchesAny(s.Exclude, target) {
+				selected[key] = true
+			}
+		}
+	}
+	if withDependencies {
+		var selectedKeys []string
+		for key := range selected {
+			selectedKeys = append(selectedKeys, key)
+		}
+		for _, key := range graph.TransitiveDependencies(selectedKeys...) {
+			selected[key] = true
+		}
+	}
+
+	var result []*buildTarget
+	for _, target := range targets {
+		if selected[target.ImageTag()] {
+			result = append(result, target)
+		}
+	}
+	return result, nil
+}
+
+// selectBuiltTargets returns the selected targets that have been built. The dependency graph is only resolved
+// from the dist directory if dependents have to be added.
+func selectBuiltTargets(project *model.ContainerHiveProject, distPath string, s *selectionOptions) ([]*buildTarget, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	var graph *dependency.Graph
+	if s.WithDependents {
+		resolved, err := resolveDependencyGraph(distPath, project)
+		if err != nil {
+			return nil, err
+		}
+		graph = resolved.Graph
+	}
+	return s.selectTargets(builtTargets(project, distPath), graph, false)
+}
+
+// targetKeys returns the graph nodes of the targets.
+func targetKeys(targets []*buildTarget) []string {
+	keys := make([]string, len(targets))
+	for i, target := range targets {
+		keys[i] = target.ImageTag()
+	}
+	return keys
+}
+
+// shardTargets returns the targets of the shard. Shards are balanced by the build durations from the reports at
+// weightsPaths, a shared base built by several shards weighs its longest duration.
+func shardTargets(targets []*buildTarget, graph *dependency.Graph, shard scheduler.Shard, weightsPaths []string) ([]*buildTarget, error) {
+	weights := make(map[string]float64)
+	for _, weightsPath := range weightsPaths {
+		previous, err := report.Load(weightsPath)
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to load shard weights"), err)
+		}
+		for key, duration := range previous.BuildDurations() {
+			weights[key] = max(weights[key], duration)
+		}
+	}
+
+	nodes := shard.Nodes(graph, weights)
+	var result []*buildTarget
+	for _, target := range targets {
+		if slices.Contains(nodes, target.ImageTag()) {
+			result = append(result, target)
+		}
+	}
+	log.Printf("Shard %s: building %d of %d target(s)", shard, len(result), len(targets))
+	return result, nil
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+	"github.com/timo-reymann/ContainerHive/internal/report"
+	"github.com/timo-reymann/ContainerHive/internal/scheduler"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+func selectionFixture() ([]*buildTarget, *dependency.Graph) {
+	ubuntu := &model.Image{Name: "ubuntu"}
+	python := &model.Image{Name: "python"}
+	app := &model.Image{Name: "app"}
+	node := &model.ImageVariant{Name: "node", TagSuffix: "-node"}
+
+	targets := []*buildTarget{
+		{Image: ubuntu, Tag: &model.Tag{Name: "22.04"}},
+		{Image: python, Tag: &model.Tag{Name: "3.12"}},
+		{Image: python, Tag: &model.Tag{Name: "3.13"}},
+		{Image: python, Tag: &model.Tag{Name: "3.13"}, Variant: node},
+		{Image: app, Tag: &model.Tag{Name: "1.0"}},
+	}
+
+	graph := dependency.NewGraph()
+	for _, target := range targets {
+		graph.AddImage(target.ImageTag())
+	}
+	graph.AddDependency("python:3.12", "ubuntu:22.04")
+	graph.AddDependency("python:3.13", "ubuntu:22.04")
+	graph.AddDependency("python:3.13-node", "python:3.13")
+	graph.AddDependency("app:1.0", "python:3.13-node")
+	return targets, graph
+}
+
+func TestSelectionOptions_SelectTargets(t *testing.T) {
+	targets, graph := selectionFixture()
+
+	testCases := []struct {
+		name             string
+		selection        selectionOptions
+		withDependencies bool
+		expected         []string
+	}{
+		{
+			name:     "everything without selectors",
+			expected: []string{"ubuntu:22.04", "python:3.12", "python:3.13", "python:3.13-node", "app:1.0"},
+		},
+		{
+			name:      "glob over name and tag",
+			selection: selectionOptions{Only: []string{"python:3.13*"}},
+			expected:  []string{"python:3.13", "python:3.13-node"},
+		},
+		{
+			name:      "variant suffix",
+			selection: selectionOptions{Only: []string{"*:*-node"}},
+			expected:  []string{"python:3.13-node"},
+		},
+		{
+			name:      "image name",
+			selection: selectionOptions{Only: []string{"python"}, Exclude: []string{"python:3.12"}},
+			expected:  []string{"python:3.13", "python:3.13-node"},
+		},
+		{
+			name:      "exclude only",
+			selection: selectionOptions{Exclude: []string{"python", "app"}},
+			expected:  []string{"ubuntu:22.04"},
+		},
+		{
+			name:             "dependencies are pulled in",
+			selection:        selectionOptions{Only: []string{"app:*"}},
+			withDependencies: true,
+			expected:         []string{"ubuntu:22.04", "python:3.13", "python:3.13-node", "app:1.0"},
+		},
+		{
+			name:             "excluded dependencies are still pulled in",
+			selection:        selectionOptions{Only: []string{"app:*"}, Exclude: []string{"ubuntu"}},
+			withDependencies: true,
+			expected:         []string{"ubuntu:22.04", "python:3.13", "python:3.13-node", "app:1.0"},
+		},
+		{
+			name:      "with dependents",
+			selection: selectionOptions{Only: []string{"python:3.13"}, WithDependents: true},
+			expected:  []string{"python:3.13", "python:3.13-node", "app:1.0"},
+		},
+		{
+			name:      "excluded dependents are skipped",
+			selection: selectionOptions{Only: []string{"python:3.13"}, Exclude: []string{"app"}, WithDependents: true},
+			expected:  []string{"python:3.13", "python:3.13-node"},
+		},
+		{
+			name:             "dependents and their dependencies",
+			selection:        selectionOptions{Only: []string{"python:3.13"}, WithDependents: true},
+			withDependencies: true,
+			expected:         []string{"ubuntu:22.04", "python:3.13", "python:3.13-node", "app:1.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, err := tc.selection.selectTargets(targets, graph, tc.withDependencies)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, target := range selected {
+				got = append(got, target.ImageTag())
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("selectTargets() mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSelectionOptions_NoMatch(t *testing.T) {
+	targets, graph := selectionFixture()
+	selection := selectionOptions{Only: []string{"golang:*"}}
+	if _, err := selection.selectTargets(targets, graph, true); err == nil {
+		t.Error("expected error if no target matches")
+	}
+}
+
+func TestSelectionOptions_Validate(t *testing.T) {
+	if err := (&selectionOptions{Only: []string{"python:3.1[3"}}).validate(); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if err := (&selectionOptions{Only: []string{"python:3.1[23]"}, Exclude: []string{"*:*-node"}}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestShardTargets(t *testing.T) {
+	targets, graph := selectionFixture()
+	golang := &model.Image{Name: "golang"}
+	targets = append(targets, &buildTarget{Image: golang, Tag: &model.Tag{Name: "1.25"}})
+	graph.AddImage("golang:1.25")
+
+	// Each shard of the previous run only reports its own targets
+	dir := t.TempDir()
+	weights := []string{filepath.Join(dir, "shard-1.json"), filepath.Join(dir, "shard-2.json")}
+	first, second := report.New(), report.New()
+	for _, target := range targets[:len(targets)-1] {
+		second.SetBuild(target.reportTarget(), report.BuildResult{Status: report.StatusSucceeded, Duration: 10})
+	}
+	first.SetBuild(report.Target{Image: "golang", Tag: "1.25"}, report.BuildResult{Status: report.StatusSucceeded, Duration: 900})
+	if err := first.Save(weights[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Save(weights[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	var shards [][]string
+	for i := 1; i <= 2; i++ {
+		shardedTargets, err := shardTargets(targets, graph, scheduler.Shard{Index: i, Count: 2}, weights)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		shards = append(shards, targetKeys(shardedTargets))
+	}
+
+	expected := [][]string{
+		{"golang:1.25"},
+		{"ubuntu:22.04", "python:3.12", "python:3.13", "python:3.13-node", "app:1.0"},
+	}
+	if diff := cmp.Diff(expected, shards); diff != "" {
+		t.Errorf("shardTargets() mismatch (-expected +got):\n%s", diff)
+	}
+}
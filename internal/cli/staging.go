@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/timo-reymann/ContainerHive/internal/buildkit"
+	"github.com/timo-reymann/ContainerHive/internal/buildkit/build_context"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+	"github.com/timo-reymann/ContainerHive/internal/oci_layout"
+	"github.com/timo-reymann/ContainerHive/internal/scheduler"
+	"github.com/timo-reymann/ContainerHive/internal/utils"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+const (
+	stagingAuto      = "auto"
+	stagingRegistry  = "registry"
+	stagingOCILayout = "oci-layout"
+)
+
+var stagingModes = []string{stagingAuto, stagingRegistry, stagingOCILayout}
+
+// parseStagingMode validates the staging mode and resolves auto,
+// which uses the staging registry in CI (CI env var set) and OCI layouts otherwise.
+func parseStagingMode(name string) (string, error) {
+	if !slices.Contains(stagingModes, name) {
+		return "", fmt.Errorf("unsupported staging mode %q, expected one of %s", name, strings.Join(stagingModes, ", "))
+	}
+	if name != stagingAuto {
+		return name, nil
+	}
+	if os.Getenv("CI") != "" {
+		return stagingRegistry, nil
+	}
+	return stagingOCILayout, nil
+}
+
+// validateNoDeps checks that __hive__/ bases built by other jobs can be pulled with --no-deps,
+// which requires them to be staged in a shared staging registry.
+func validateNoDeps(staging string, project *model.ContainerHiveProject, referenced *dependency.Graph) error {
+	if !referenced.HasDependencies() {
+		return nil
+	}
+	if staging != stagingRegistry {
+		return fmt.Errorf("--no-deps requires the %s staging mode, bases built by other jobs are pulled from the staging registry", stagingRegistry)
+	}
+	if project.Config.Registries.Staging == "" {
+		return errors.New("--no-deps requires registries.staging in the project config, bases built by other jobs are pulled from it")
+	}
+	return nil
+}
+
+// shardNamespace returns the namespace in the staging registry the bases of a shard are staged in. Shards build shared
+// bases themselves, so each one stages them separately instead of overwriting the images of other shards running in
+// parallel. With --no-deps bases are built once by other jobs and pulled from the staging registry itself.
+func shardNamespace(shard *scheduler.Shard, noDeps bool) string {
+	if shard == nil || noDeps {
+		return ""
+	}
+	return fmt.Sprintf("shard-%d-of-%d", shard.Index, shard.Count)
+}
+
+// staged reports whether the target is staged for other images referencing it via __hive__/.
+func (b *imageBuilder) staged(target *buildTarget) bool {
+	return b.staging != "" && len(b.referenced.Dependents(target.ImageTag())) > 0
+}
+
+// hivePrefix returns what __hive__/ in the Dockerfiles is replaced with, empty if nothing is staged.
+func (b *imageBuilder) hivePrefix() string {
+	switch b.staging {
+	case stagingRegistry:
+		return b.registry.Address() + "/"
+	case stagingOCILayout:
+		return build_context.HiveContextPrefix
+	default:
+		return ""
+	}
+}
+
+// layoutContexts maps the __hive__/ bases of the target to the OCI layouts of their builds,
+// nil unless bases are staged as OCI layouts.
+func (b *imageBuilder) layoutContexts(target *buildTarget) (map[string]buildkit.OCILayoutContext, error) {
+	if b.staging != stagingOCILayout {
+		return nil, nil
+	}
+	bases := b.referenced.Dependencies(target.ImageTag())
+	if len(bases) == 0 {
+		return nil, nil
+	}
+
+	contexts := make(map[string]buildkit.OCILayoutContext, len(bases))
+	for _, key := range bases {
+		base, ok := b.targets[key]
+		if !ok {
+			return nil, fmt.Errorf("base %s of %s is not part of the build", key, target.ImageTag())
+		}
+		digest, err := oci_layout.ReadDirDigest(base.LayoutDir())
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to read OCI layout of base %s", key), err)
+		}
+		contexts[build_context.HiveContextName(key)] = buildkit.OCILayoutContext{
+			Dir:    base.LayoutDir(),
+			Digest: digest.String(),
+		}
+	}
+	return contexts, nil
+}
+
+// stageReused stages a target carried over from the previous run from its OCI tar.
+func (b *imageBuilder) stageReused(ctx context.Context, target *buildTarget) error {
+	imageTag := target.ImageTag()
+	switch b.staging {
+	case stagingRegistry:
+		if err := b.registry.Push(ctx, target.Image.Name, target.TagName(), target.TarFile()); err != nil {
+			return errors.Join(fmt.Errorf("failed to push %s to registry", imageTag), err)
+		}
+		log.Printf("Pushed %s to registry", imageTag)
+	case stagingOCILayout:
+		// The layout was carried over as well if it is exported
+		if _, err := os.Stat(target.LayoutDir()); !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err := utils.ExtractTar(target.TarFile(), target.LayoutDir()); err != nil {
+			return errors.Join(fmt.Errorf("failed to unpack OCI layout of %s", imageTag), err)
+		}
+	}
+	return nil
+}
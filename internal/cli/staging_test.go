@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/timo-reymann/ContainerHive/internal/buildkit"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+	"github.com/timo-reymann/ContainerHive/internal/scheduler"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+	"github.com/timo-reymann/ContainerHive/pkg/rendering"
+)
+
+func TestParseStagingMode(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mode      string
+		ci        string
+		expected  string
+		expectErr bool
+	}{
+		{name: "auto locally", mode: stagingAuto, expected: stagingOCILayout},
+		{name: "auto in CI", mode: stagingAuto, ci: "true", expected: stagingRegistry},
+		{name: "registry locally", mode: stagingRegistry, expected: stagingRegistry},
+		{name: "oci-layout in CI", mode: stagingOCILayout, ci: "true", expected: stagingOCILayout},
+		{name: "unsupported", mode: "nfs", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CI", tc.ci)
+			mode, err := parseStagingMode(tc.mode)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mode != tc.expected {
+				t.Errorf("expected mode %s, got %s", tc.expected, mode)
+			}
+		})
+	}
+}
+
+func TestValidateNoDeps(t *testing.T) {
+	referenced := dependency.NewGraph()
+	referenced.AddImage("ubuntu:22.04")
+	referenced.AddImage("python:3.13")
+	referenced.AddDependency("python:3.13", "ubuntu:22.04")
+
+	withStaging := &model.ContainerHiveProject{Config: &model.HiveProjectConfig{
+		Registries: model.RegistriesConfig{Staging: "registry.example.com/staging"},
+	}}
+	withoutStaging := &model.ContainerHiveProject{Config: &model.HiveProjectConfig{}}
+
+	testCases := []struct {
+		name       string
+		staging    string
+		project    *model.ContainerHiveProject
+		referenced *dependency.Graph
+		expectErr  bool
+	}{
+		{name: "staging registry", staging: stagingRegistry, project: withStaging, referenced: referenced},
+		{name: "oci-layout staging", staging: stagingOCILayout, project: withStaging, referenced: referenced, expectErr: true},
+		{name: "no staging registry configured", staging: stagingRegistry, project: withoutStaging, referenced: referenced, expectErr: true},
+		{name: "no references", staging: stagingOCILayout, project: withoutStaging, referenced: dependency.NewGraph()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateNoDeps(tc.staging, tc.project, tc.referenced)
+			if tc.expectErr && err == nil {
+				t.Fatal("expected error")
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// writeLayout writes a random image as OCI layout into dir and returns its digest.
+func writeLayout(t *testing.T, dir string) string {
+	t.Helper()
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AppendImage(img); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return digest.String()
+}
+
+func TestShardNamespace(t *testing.T) {
+	shard := &scheduler.Shard{Index: 2, Count: 4}
+	if got := shardNamespace(shard, false); got != "shard-2-of-4" {
+		t.Errorf("expected shard-2-of-4, got %q", got)
+	}
+	if got := shardNamespace(shard, true); got != "" {
+		t.Errorf("expected no namespace with --no-deps, got %q", got)
+	}
+	if got := shardNamespace(nil, false); got != "" {
+		t.Errorf("expected no namespace without shard, got %q", got)
+	}
+}
+
+func TestImageBuilder_OCILayoutStaging(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/dependency-project")
+	dist := filepath.Join(t.TempDir(), "dist")
+	if err := rendering.RenderProject(t.Context(), project, dist); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := make(map[string]*buildTarget)
+	for _, target := range collectTargets(project, dist, imageNames(project)) {
+		targets[target.ImageTag()] = target
+	}
+	ubuntu := targets["ubuntu:22.04"]
+	python := targets["python:3.13"]
+
+	referenced := dependency.NewGraph()
+	referenced.AddImage("ubuntu:22.04")
+	referenced.AddImage("python:3.13")
+	referenced.AddDependency("python:3.13", "ubuntu:22.04")
+
+	builder := &imageBuilder{
+		referenced: referenced,
+		staging:    stagingOCILayout,
+		targets:    targets,
+	}
+
+	if prefix := builder.hivePrefix(); prefix != "containerhive.local/" {
+		t.Errorf("expected named context prefix, got %q", prefix)
+	}
+
+	t.Run("fails without OCI layout of base", func(t *testing.T) {
+		if _, err := builder.layoutContexts(python); err == nil {
+			t.Fatal("expected error for missing OCI layout")
+		}
+	})
+
+	t.Run("unpacks OCI layout of reused target", func(t *testing.T) {
+		source := t.TempDir()
+		writeLayout(t, source)
+		f, err := os.Create(ubuntu.TarFile())
+		if err != nil {
+			t.Fatal(err)
+		}
+		tw := tar.NewWriter(f)
+		if err := tw.AddFS(os.DirFS(source)); err != nil {
+			t.Fatal(err)
+		}
+		tw.Close()
+		f.Close()
+		defer os.RemoveAll(ubuntu.LayoutDir())
+
+		if err := builder.stageReused(t.Context(), ubuntu); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(ubuntu.LayoutDir(), "index.json")); err != nil {
+			t.Errorf("expected unpacked OCI layout: %v", err)
+		}
+	})
+
+	digest := writeLayout(t, ubuntu.LayoutDir())
+
+	t.Run("maps bases to their OCI layouts", func(t *testing.T) {
+		contexts, err := builder.layoutContexts(python)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := map[string]buildkit.OCILayoutContext{
+			"containerhive.local/ubuntu:22.04": {Dir: ubuntu.LayoutDir(), Digest: digest},
+		}
+		if diff := cmp.Diff(expected, contexts); diff != "" {
+			t.Errorf("layoutContexts() mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("no contexts without bases", func(t *testing.T) {
+		contexts, err := builder.layoutContexts(ubuntu)
+		if err != nil || contexts != nil {
+			t.Errorf("expected no contexts, got %v (%v)", contexts, err)
+		}
+	})
+
+	t.Run("keeps carried over OCI layout", func(t *testing.T) {
+		if err := builder.stageReused(t.Context(), ubuntu); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("no contexts with staging registry", func(t *testing.T) {
+		builder.staging = stagingRegistry
+		defer func() { builder.staging = stagingOCILayout }()
+		contexts, err := builder.layoutContexts(python)
+		if err != nil || contexts != nil {
+			t.Errorf("expected no contexts, got %v (%v)", contexts, err)
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/timo-reymann/ContainerHive/internal/buildconfig_resolver"
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+	"github.com/timo-reymann/ContainerHive/internal/report"
+	"github.com/timo-reymann/ContainerHive/internal/semantic_tags"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+// buildTarget is a single buildable artifact of an image, either a plain tag or a tag variant.
+type buildTarget struct {
+	Image   *model.Image
+	Tag     *model.Tag
+	Variant *model.ImageVariant
+	DistDir string
+	// Aliases are the floating tags published in addition to the tag, e.g. 3.13 and 3 for 3.13.7
+	Aliases []string
+}
+
+// tagSuffix returns the tag suffix of the variant or an empty string for plain tags.
+func (b *buildTarget) tagSuffix() string {
+	if b.Variant == nil {
+		return ""
+	}
+	return b.Variant.TagSuffix
+}
+
+// TagName returns the full tag of the target including the variant suffix.
+func (b *buildTarget) TagName() string {
+	return b.Tag.Name + b.tagSuffix()
+}
+
+// PublishTags returns the tag of the target followed by its floating tags.
+func (b *buildTarget) PublishTags() []string {
+	return append([]string{b.TagName()}, b.Aliases...)
+}
+
+// ImageTag returns the image reference in the form name:tag, which is also the key of the target in the dependency graph.
+func (b *buildTarget) ImageTag() string {
+	return dependency.TargetKey(b.Image.Name, b.TagName())
+}
+
+// reportTarget identifies the target in the build report.
+func (b *buildTarget) reportTarget() report.Target {
+	t := report.Target{Image: b.Image.Name, Tag: b.TagName()}
+	if b.Variant != nil {
+		t.Variant = b.Variant.Name
+	}
+	return t
+}
+
+// TarFile returns the OCI tar output path inside the rendered dist directory.
+func (b *buildTarget) TarFile() string {
+	return filepath.Join(b.DistDir, "image.tar")
+}
+
+// LayoutDir returns the OCI layout output directory inside the rendered dist directory.
+func (b *buildTarget) LayoutDir() string {
+	return filepath.Join(b.DistDir, "oci-layout")
+}
+
+// Platforms returns the platforms to build the target for.
+// Variant platforms take precedence over tag platforms, which take precedence over the image platforms.
+// Image platforms already include the project defaults, the host platform is used if none are configured.
+func (b *buildTarget) Platforms() []string {
+	switch {
+	case b.Variant != nil && len(b.Variant.Platforms) > 0:
+		return b.Variant.Platforms
+	case len(b.Tag.Platforms) > 0:
+		return b.Tag.Platforms
+	case len(b.Image.Platforms) > 0:
+		return b.Image.Platforms
+	default:
+		return []string{hostPlatform()}
+	}
+}
+
+// BuildArgs returns the build args of the target without resolving its secrets.
+func (b *buildTarget) BuildArgs() model.BuildArgs {
+	if b.Variant == nil {
+		return buildconfig_resolver.ArgsForTag(b.Image, b.Tag).ToBuildArgs()
+	}
+	return buildconfig_resolver.ArgsForTagVariant(b.Image, b.Variant, b.Tag).ToBuildArgs()
+}
+
+// SecretNames returns the sorted names of the secrets passed to the build of the target without resolving them.
+func (b *buildTarget) SecretNames() []string {
+	names := slices.Collect(maps.Keys(b.Image.Secrets))
+	names = slices.AppendSeq(names, maps.Keys(b.Tag.Secrets))
+	if b.Variant != nil {
+		names = slices.AppendSeq(names, maps.Keys(b.Variant.Secrets))
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// ResolveBuildValues resolves versions, build args and secrets for the target.
+func (b *buildTarget) ResolveBuildValues() (*buildconfig_resolver.ResolvedBuildValues, error) {
+	if b.Variant == nil {
+		return buildconfig_resolver.ForTag(b.Image, b.Tag)
+	}
+	return buildconfig_resolver.ForTagVariant(b.Image, b.Variant, b.Tag)
+}
+
+// hostPlatform returns the linux platform matching the architecture of the host.
+func hostPlatform() string {
+	return "linux/" + runtime.GOARCH
+}
+
+// platformFileSuffix returns the platform in a form that can be used in file names, e.g. linux-amd64.
+func platformFileSuffix(platform string) string {
+	return strings.ReplaceAll(platform, "/", "-")
+}
+
+// TestDefinitions returns the rendered container-structure-test definition files of the target.
+func (b *buildTarget) TestDefinitions() []string {
+	testsDir := filepath.Join(b.DistDir, "tests")
+	entries, err := os.ReadDir(testsDir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			paths = append(paths, filepath.Join(testsDir, e.Name()))
+		}
+	}
+	return paths
+}
+
+// collectTargets returns all build targets of the project for the given image names in order.
+// Each tag is followed by its variants; images, tags and variants are sorted for determinism.
+// Targets of images that opted into floating tags get their aliases assigned.
+func collectTargets(project *model.ContainerHiveProject, distPath string, imageNames []string) []*buildTarget {
+	var targets []*buildTarget
+	for _, name := range imageNames {
+		images := slices.SortedFunc(slices.Values(project.ImagesByName[name]), func(a, b *model.Image) int {
+			return strings.Compare(a.Identifier, b.Identifier)
+		})
+		for _, image := range images {
+			for _, tagName := range slices.Sorted(maps.Keys(image.Tags)) {
+				tag := image.Tags[tagName]
+				targets = append(targets, &buildTarget{
+					Image:   image,
+					Tag:     tag,
+					DistDir: filepath.Join(distPath, image.Name, tagName),
+				})
+				for _, variantName := range slices.Sorted(maps.Keys(image.Variants)) {
+					variant := image.Variants[variantName]
+					targets = append(targets, &buildTarget{
+						Image:   image,
+						Tag:     tag,
+						Variant: variant,
+						DistDir: filepath.Join(distPath, image.Name, tagName+variant.TagSuffix),
+					})
+				}
+			}
+		}
+	}
+	assignFloatingTags(targets)
+	return targets
+}
+
+// assignFloatingTags sets the aliases of all targets whose image opted into floating tags.
+// Tags are grouped by image name and variant suffix, so every variant floats on its own.
+func assignFloatingTags(targets []*buildTarget) {
+	type tagGroup struct {
+		image  string
+		suffix string
+	}
+
+	tagsByGroup := make(map[tagGroup][]string)
+	for _, target := range targets {
+		group := tagGroup{target.Image.Name, target.tagSuffix()}
+		tagsByGroup[group] = append(tagsByGroup[group], target.Tag.Name)
+	}
+
+	aliasesByGroup := make(map[tagGroup]map[string][]string, len(tagsByGroup))
+	for group, tags := range tagsByGroup {
+		aliasesByGroup[group] = semantic_tags.FloatingTags(tags, true)
+	}
+
+	for _, target := range targets {
+		config := target.Image.FloatingTags
+		if !config.Enabled {
+			continue
+		}
+		suffix := target.tagSuffix()
+		for _, alias := range aliasesByGroup[tagGroup{target.Image.Name, suffix}][target.Tag.Name] {
+			if alias == semantic_tags.LatestTag && !config.Latest {
+				continue
+			}
+			target.Aliases = append(target.Aliases, alias+suffix)
+		}
+	}
+}
+
+// imageNames returns the sorted names of all images in the project.
+func imageNames(project *model.ContainerHiveProject) []string {
+	return slices.Sorted(maps.Keys(project.ImagesByName))
+}
+
+// builtTargets returns all targets of the project that have an image tar in the dist directory.
+func builtTargets(project *model.ContainerHiveProject, distPath string) []*buildTarget {
+	var targets []*buildTarget
+	for _, target := range collectTargets(project, distPath, imageNames(project)) {
+		if _, err := os.Stat(target.TarFile()); err != nil {
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/pkg/discovery"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+	"github.com/timo-reymann/ContainerHive/pkg/rendering"
+)
+
+func mustDiscover(t *testing.T, root string) *model.ContainerHiveProject {
+	t.Helper()
+	project, err := discovery.DiscoverProject(t.Context(), root)
+	if err != nil {
+		t.Fatalf("failed to discover project: %v", err)
+	}
+	return project
+}
+
+func TestCollectTargets(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/simple-project")
+
+	targets := collectTargets(project, "dist", []string{"python", "dotnet"})
+
+	var got []string
+	for _, target := range targets {
+		got = append(got, target.ImageTag()+" "+target.DistDir)
+	}
+
+	expected := []string{
+		"python:3.13.7 " + filepath.Join("dist", "python", "3.13.7"),
+		"dotnet:8.0.100 " + filepath.Join("dist", "dotnet", "8.0.100"),
+		"dotnet:8.0.100-node " + filepath.Join("dist", "dotnet", "8.0.100-node"),
+		"dotnet:8.0.200 " + filepath.Join("dist", "dotnet", "8.0.200"),
+		"dotnet:8.0.200-node " + filepath.Join("dist", "dotnet", "8.0.200-node"),
+		"dotnet:8.0.300 " + filepath.Join("dist", "dotnet", "8.0.300"),
+		"dotnet:8.0.300-node " + filepath.Join("dist", "dotnet", "8.0.300-node"),
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("collectTargets() mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestCollectTargets_FloatingTags(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/floating-tags-project")
+
+	got := make(map[string][]string)
+	for _, target := range collectTargets(project, "dist", []string{"python"}) {
+		got[target.ImageTag()] = target.PublishTags()
+	}
+
+	expected := map[string][]string{
+		"python:3.12.11":      {"3.12.11", "3.12"},
+		"python:3.12.11-slim": {"3.12.11-slim", "3.12-slim"},
+		"python:3.13.6":       {"3.13.6"},
+		"python:3.13.6-slim":  {"3.13.6-slim"},
+		"python:3.13.7":       {"3.13.7", "3.13", "3", "latest"},
+		"python:3.13.7-slim":  {"3.13.7-slim", "3.13-slim", "3-slim", "latest-slim"},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("publish tags mismatch (-expected +got):\n%s", diff)
+	}
+
+	t.Run("latest requires opt-in", func(t *testing.T) {
+		project.ImagesByName["python"][0].FloatingTags.Latest = false
+		targets := collectTargets(project, "dist", []string{"python"})
+		if diff := cmp.Diff([]string{"3.13.7", "3.13", "3"}, targets[4].PublishTags()); diff != "" {
+			t.Errorf("publish tags mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("no aliases without opt-in", func(t *testing.T) {
+		for _, target := range collectTargets(mustDiscover(t, "../../pkg/testdata/simple-project"), "dist", []string{"dotnet"}) {
+			if len(target.Aliases) > 0 {
+				t.Errorf("expected no aliases for %s, got %v", target.ImageTag(), target.Aliases)
+			}
+		}
+	})
+}
+
+func TestBuildTarget_ResolveBuildValues(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/simple-project")
+	targets := collectTargets(project, "dist", []string{"dotnet"})
+
+	t.Run("tag", func(t *testing.T) {
+		values, err := targets[0].ResolveBuildValues()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if values.Versions["dotnet-sdk-channel"] != "8.0.1xx" {
+			t.Errorf("unexpected versions %v", values.Versions)
+		}
+		if _, ok := values.Versions["nodejs"]; ok {
+			t.Errorf("tag should not contain variant versions, got %v", values.Versions)
+		}
+		if _, ok := values.Secrets["npm_token"]; ok {
+			t.Error("tag should not receive variant secrets")
+		}
+	})
+
+	t.Run("variant", func(t *testing.T) {
+		values, err := targets[1].ResolveBuildValues()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if values.Versions["nodejs"] != "24" {
+			t.Errorf("expected variant versions to be merged, got %v", values.Versions)
+		}
+		if string(values.Secrets["npm_token"]) != "npm-token" {
+			t.Errorf("expected variant secret to be resolved, got %q", values.Secrets["npm_token"])
+		}
+	})
+}
+
+func TestBuildTarget_BuildArgsWithoutSecrets(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/simple-project")
+	project.ImagesByName["dotnet"][0].Secrets = model.Secrets{
+		"api_key": {SourceType: "env", Value: "${CONTAINER_HIVE_TEST_UNSET_SECRET}"},
+	}
+	targets := collectTargets(project, "dist", []string{"dotnet"})
+
+	if _, err := targets[1].ResolveBuildValues(); err == nil {
+		t.Fatal("expected the unset secret to fail resolving the build values")
+	}
+
+	t.Run("build args", func(t *testing.T) {
+		if targets[1].BuildArgs()["NODEJS_VERSION"] != "24" {
+			t.Errorf("expected variant versions to be merged, got %v", targets[1].BuildArgs())
+		}
+	})
+
+	t.Run("secret names", func(t *testing.T) {
+		if diff := cmp.Diff([]string{"api_key"}, targets[0].SecretNames()); diff != "" {
+			t.Errorf("tag secret names mismatch (-expected +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"api_key", "npm_token"}, targets[1].SecretNames()); diff != "" {
+			t.Errorf("variant secret names mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("dependency graph", func(t *testing.T) {
+		dist := filepath.Join(t.TempDir(), "dist")
+		if err := rendering.RenderProject(t.Context(), project, dist); err != nil {
+			t.Fatalf("rendering should not resolve secrets: %v", err)
+		}
+		if _, err := resolveDependencyGraph(dist, project); err != nil {
+			t.Errorf("graph resolution should not resolve secrets: %v", err)
+		}
+	})
+}
+
+func TestBuildTarget_Platforms(t *testing.T) {
+	image := &model.Image{Name: "app"}
+	tag := &model.Tag{Name: "1.0"}
+	variant := &model.ImageVariant{Name: "slim", TagSuffix: "-slim"}
+
+	testCases := []struct {
+		name     string
+		image    []string
+		tag      []string
+		variant  []string
+		target   *buildTarget
+		expected []string
+	}{
+		{
+			name:     "host platform without configuration",
+			target:   &buildTarget{Image: image, Tag: tag},
+			expected: []string{"linux/" + runtime.GOARCH},
+		},
+		{
+			name:     "image platforms",
+			image:    []string{"linux/amd64", "linux/arm64"},
+			target:   &buildTarget{Image: image, Tag: tag},
+			expected: []string{"linux/amd64", "linux/arm64"},
+		},
+		{
+			name:     "tag overrides image",
+			image:    []string{"linux/amd64", "linux/arm64"},
+			tag:      []string{"linux/arm64"},
+			target:   &buildTarget{Image: image, Tag: tag},
+			expected: []string{"linux/arm64"},
+		},
+		{
+			name:     "variant overrides tag",
+			image:    []string{"linux/amd64", "linux/arm64"},
+			tag:      []string{"linux/arm64"},
+			variant:  []string{"linux/amd64"},
+			target:   &buildTarget{Image: image, Tag: tag, Variant: variant},
+			expected: []string{"linux/amd64"},
+		},
+		{
+			name:     "variant falls back to tag",
+			tag:      []string{"linux/arm64"},
+			target:   &buildTarget{Image: image, Tag: tag, Variant: variant},
+			expected: []string{"linux/arm64"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			image.Platforms = tc.image
+			tag.Platforms = tc.tag
+			variant.Platforms = tc.variant
+			if diff := cmp.Diff(tc.expected, tc.target.Platforms()); diff != "" {
+				t.Errorf("Platforms() mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlatformFileSuffix(t *testing.T) {
+	if got := platformFileSuffix("linux/arm64/v8"); got != "linux-arm64-v8" {
+		t.Errorf("unexpected suffix %q", got)
+	}
+}
+
+func TestBuildTarget_TestDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	testsDir := filepath.Join(dir, "tests")
+	if err := os.MkdirAll(filepath.Join(testsDir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"image.yml", "variant.yml"} {
+		if err := os.WriteFile(filepath.Join(testsDir, name), []byte("schemaVersion: 2.0.0"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	target := &buildTarget{DistDir: dir}
+	expected := []string{filepath.Join(testsDir, "image.yml"), filepath.Join(testsDir, "variant.yml")}
+	if diff := cmp.Diff(expected, target.TestDefinitions()); diff != "" {
+		t.Errorf("TestDefinitions() mismatch (-expected +got):\n%s", diff)
+	}
+
+	if defs := (&buildTarget{DistDir: t.TempDir()}).TestDefinitions(); defs != nil {
+		t.Errorf("expected no test definitions, got %v", defs)
+	}
+}
+
+func TestBuiltTargets(t *testing.T) {
+	project := mustDiscover(t, "../../pkg/testdata/minimal-project")
+	dist := t.TempDir()
+
+	if targets := builtTargets(project, dist); len(targets) != 0 {
+		t.Fatalf("expected no built targets, got %d", len(targets))
+	}
+
+	tagDir := filepath.Join(dist, "nginx", "1.27")
+	if err := os.MkdirAll(tagDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tagDir, "image.tar"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := builtTargets(project, dist)
+	if len(targets) != 1 || targets[0].ImageTag() != "nginx:1.27" {
+		t.Errorf("expected nginx:1.27 to be built, got %v", targets)
+	}
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/timo-reymann/ContainerHive/internal/container_structure_test"
+	"github.com/timo-reymann/ContainerHive/internal/docker"
+	"github.com/timo-reymann/ContainerHive/internal/report"
+	"github.com/timo-reymann/ContainerHive/internal/scheduler"
+)
+
+func newTestCommand(opts *globalOptions) *cobra.Command {
+	selection := &selectionOptions{}
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Run container-structure-tests against all built images in the dist directory",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			policy, err := opts.failurePolicy()
+			if err != nil {
+				return err
+			}
+
+			project, err := discoverProject(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+
+			reportDir := opts.reportPath(project)
+			if err := os.MkdirAll(reportDir, 0755); err != nil {
+				return errors.Join(errors.New("failed to create report directory"), err)
+			}
+
+			reportFile := filepath.Join(reportDir, report.FileName)
+			buildReport, err := report.Load(reportFile)
+			if err != nil {
+				return err
+			}
+
+			targets, err := selectBuiltTargets(project, opts.distPath(project), selection)
+			if err != nil {
+				return err
+			}
+
+			dockerClient, err := docker.NewClient()
+			if err != nil {
+				return errors.Join(errors.New("failed to initialize Docker client"), err)
+			}
+			defer dockerClient.Close()
+
+			var failures []error
+		targets:
+			for _, target := range targets {
+				for _, platform := range target.Platforms() {
+					result := runContainerStructureTests(dockerClient, target, platform, reportDir)
+					buildReport.SetTest(target.reportTarget(), result)
+					if result.Status != report.StatusFailed {
+						continue
+					}
+					failures = append(failures, fmt.Errorf("%s (%s): %s", target.ImageTag(), platform, result.Error))
+					if policy == scheduler.FailFast {
+						break targets
+					}
+				}
+			}
+
+			if err := buildReport.Save(reportFile); err != nil {
+				return err
+			}
+			if len(failures) > 0 {
+				return errors.Join(append([]error{errTestFailed}, failures...)...)
+			}
+			return nil
+		},
+	}
+	selection.addFlags(cmd)
+	return cmd
+}
+
+// runContainerStructureTests runs container structure tests for a platform of a built target.
+func runContainerStructureTests(dockerClient *docker.Client, target *buildTarget, platform, reportDir string) report.TestResult {
+	imageTag := target.ImageTag() + " (" + platform + ")"
+	result := report.TestResult{Platform: platform, Status: report.StatusSkipped}
+	testDefs := target.TestDefinitions()
+	if len(testDefs) == 0 {
+		log.Printf("No container-structure-test definitions for %s, skipping", imageTag)
+		return result
+	}
+
+	reportFile := filepath.Join(reportDir, fmt.Sprintf("%s-%s-%s-cst-report.xml", target.Image.Name, target.TagName(), platformFileSuffix(platform)))
+	log.Printf("Running container-structure-tests for %s (%d test file(s))...", imageTag, len(testDefs))
+
+	runner := &container_structure_test.TestRunner{
+		TestDefinitionPaths: testDefs,
+		Image:               target.TarFile(),
+		Platform:            platform,
+		ReportFile:          reportFile,
+		DockerClient:        dockerClient,
+	}
+
+	start := time.Now()
+	summary, err := runner.Run()
+	result.Duration = time.Since(start).Seconds()
+	result.Status = report.StatusSucceeded
+	result.JUnitPath = reportFile
+	if summary != nil {
+		result.Passed = summary.Pass
+		result.Failed = summary.Fail
+	}
+	if err != nil {
+		result.Status = report.StatusFailed
+		result.Error = err.Error()
+		log.Printf("Container structure tests failed for %s: %v", imageTag, err)
+		return result
+	}
+	log.Printf("Container structure tests passed for %s -> %s", imageTag, reportFile)
+	return result
+}
@@ -41,7 +41,7 @@ func (t *TestRunner) isTar() bool {
 
 func (t *TestRunner) resolveImageName(ctx context.Context) (string, error) {
 	if t.isTar() {
-		return t.DockerClient.LoadImageFromTar(ctx, t.Image)
+		return t.DockerClient.LoadImageFromTar(ctx, t.Image, t.Platform)
 	}
 	return t.Image, nil
 }
@@ -71,21 +71,49 @@ func (t *TestRunner) runTests(channel chan interface{}, imageName string, opts *
 	close(channel)
 }
 
-func (t *TestRunner) Run() error {
+// Summary contains the number of passed and failed tests of a run.
+type Summary struct {
+	Pass int
+	Fail int
+}
+
+// countResults forwards all results from in to out and counts them in the summary.
+func countResults(in <-chan interface{}, out chan<- interface{}, summary *Summary) {
+	for result := range in {
+		if r, ok := result.(*unversioned.TestResult); ok {
+			if r.IsPass() {
+				summary.Pass++
+			} else {
+				summary.Fail++
+			}
+		}
+		out <- result
+	}
+	close(out)
+}
+
+// Run executes the tests and writes the JUnit report. The summary is returned even if tests failed,
+// in which case the error reports the failure.
+func (t *TestRunner) Run() (*Summary, error) {
 	imageName, err := t.resolveImageName(context.Background())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	opts := t.getOptions(unversioned.Junit)
-	channel := make(chan interface{}, 1)
-	go t.runTests(channel, imageName, opts)
-
 	testReportFile, err := os.Create(t.ReportFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer testReportFile.Close()
 
-	return test.ProcessResults(testReportFile, unversioned.Junit, opts.JunitSuiteName, channel)
+	opts := t.getOptions(unversioned.Junit)
+	results := make(chan interface{}, 1)
+	counted := make(chan interface{}, 1)
+	summary := &Summary{}
+	go t.runTests(results, imageName, opts)
+	go countResults(results, counted, summary)
+
+	// ProcessResults drains the counted channel, so the summary is complete once it returns
+	err = test.ProcessResults(testReportFile, unversioned.Junit, opts.JunitSuiteName, counted)
+	return summary, err
 }
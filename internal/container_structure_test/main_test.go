@@ -14,6 +14,7 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 	"github.com/timo-reymann/ContainerHive/internal/buildkit"
 	"github.com/timo-reymann/ContainerHive/internal/buildkit/build_context"
+	"github.com/timo-reymann/ContainerHive/internal/buildkit/exporter"
 	"github.com/timo-reymann/ContainerHive/internal/docker"
 	"github.com/timo-reymann/ContainerHive/internal/testutil"
 )
@@ -120,13 +121,13 @@ func TestIntegrationContainerStructureTest(t *testing.T) {
 	}
 
 	tarFile := filepath.Join(t.TempDir(), "image.tar")
-	err = bkClient.Build(ctx, &buildkit.BuildOpts{
+	_, err = bkClient.Build(ctx, &buildkit.BuildOpts{
 		ImageName: "cst-test:latest",
-		TarFile:   tarFile,
+		Exporters: []exporter.BuildkitExporter{exporter.OCITar{Path: tarFile}},
 		BuildContext: &build_context.DockerfileBuildContext{
 			Root: buildCtxDir,
 		},
-		Platform: platform,
+		Platforms: []string{platform},
 	}, drainStatus)
 	if err != nil {
 		t.Fatal("buildkit build failed:", err)
@@ -155,10 +156,13 @@ fileExistenceTests:
 			DockerClient:        dockerClient,
 		}
 
-		err := runner.Run()
+		summary, err := runner.Run()
 		if err != nil {
 			t.Fatal("container-structure-test run failed:", err)
 		}
+		if summary.Pass == 0 || summary.Fail != 0 {
+			t.Errorf("expected only passed tests, got %+v", summary)
+		}
 
 		info, err := os.Stat(reportFile)
 		if err != nil {
@@ -179,7 +183,7 @@ fileExistenceTests:
 			DockerClient:        dockerClient,
 		}
 
-		err := runner.Run()
+		_, err := runner.Run()
 		if err != nil {
 			t.Fatal("container-structure-test with docker image name failed:", err)
 		}
@@ -215,10 +219,13 @@ fileExistenceTests:
 			DockerClient:        dockerClient,
 		}
 
-		err := runner.Run()
+		summary, err := runner.Run()
 		if err == nil {
 			t.Fatal("expected container-structure-test to report failure for missing file")
 		}
+		if summary.Fail == 0 {
+			t.Errorf("expected failed tests in summary, got %+v", summary)
+		}
 
 		info, statErr := os.Stat(reportFile)
 		if statErr != nil {
@@ -0,0 +1,73 @@
+package dependency
+
+import (
+	"maps"
+	"slices"
+)
+
+// TransitiveDependencies returns all nodes the given nodes depend on directly or indirectly, sorted by name.
+// The given nodes are only part of the result if another given node depends on them.
+func (g *Graph) TransitiveDependencies(names ...string) []string {
+	return g.walk(names, g.Dependencies)
+}
+
+// TransitiveDependents returns all nodes depending on the given nodes directly or indirectly, sorted by name.
+// The given nodes are only part of the result if another given node is their dependency.
+func (g *Graph) TransitiveDependents(names ...string) []string {
+	return g.walk(names, g.Dependents)
+}
+
+func (g *Graph) walk(names []string, next func(string) []string) []string {
+	visited := make(map[string]bool)
+	queue := slices.Clone(names)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, n := range next(node) {
+			if !visited[n] {
+				visited[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(visited))
+}
+
+// Subgraph returns a graph containing only the given nodes and the edges between them, including their origins.
+func (g *Graph) Subgraph(names []string) *Graph {
+	sub := NewGraph()
+	for _, name := range names {
+		sub.AddImage(name)
+	}
+	for _, from := range names {
+		for _, to := range g.edges[from] {
+			if !sub.nodes[to] {
+				continue
+			}
+			sub.AddDependency(from, to)
+			for _, origin := range g.Origins(from, to) {
+				sub.AddDependencyWithOrigin(from, to, origin)
+			}
+		}
+	}
+	return sub
+}
+
+// TopLevel returns the nodes no other node depends on, sorted by name.
+// Every node of an acyclic graph is either top-level or a transitive dependency of a top-level node.
+func (g *Graph) TopLevel() []string {
+	dependedOn := make(map[string]bool, len(g.nodes))
+	for _, deps := range g.edges {
+		for _, to := range deps {
+			dependedOn[to] = true
+		}
+	}
+
+	var topLevel []string
+	for _, node := range g.Nodes() {
+		if !dependedOn[node] {
+			topLevel = append(topLevel, node)
+		}
+	}
+	return topLevel
+}
@@ -0,0 +1,92 @@
+package dependency
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func closureGraph() *Graph {
+	g := NewGraph()
+	for _, node := range []string{"ubuntu:22.04", "python:3.13", "python:3.13-slim", "app:1.0", "node:24"} {
+		g.AddImage(node)
+	}
+	g.AddDependencyWithOrigin("python:3.13", "ubuntu:22.04", EdgeOrigin{Kind: EdgeReference, File: "python/3.13/Dockerfile", Line: 1})
+	g.AddDependency("python:3.13-slim", "ubuntu:22.04")
+	g.AddDependency("app:1.0", "python:3.13")
+	return g
+}
+
+func TestGraph_TransitiveDependencies(t *testing.T) {
+	g := closureGraph()
+
+	testCases := []struct {
+		name     string
+		nodes    []string
+		expected []string
+	}{
+		{name: "leaf", nodes: []string{"ubuntu:22.04"}, expected: nil},
+		{name: "direct", nodes: []string{"python:3.13"}, expected: []string{"ubuntu:22.04"}},
+		{name: "transitive", nodes: []string{"app:1.0"}, expected: []string{"python:3.13", "ubuntu:22.04"}},
+		{name: "multiple", nodes: []string{"app:1.0", "python:3.13-slim"}, expected: []string{"python:3.13", "ubuntu:22.04"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, g.TransitiveDependencies(tc.nodes...)); diff != "" {
+				t.Errorf("unexpected dependencies (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGraph_TransitiveDependents(t *testing.T) {
+	g := closureGraph()
+
+	testCases := []struct {
+		name     string
+		nodes    []string
+		expected []string
+	}{
+		{name: "root", nodes: []string{"ubuntu:22.04"}, expected: []string{"app:1.0", "python:3.13", "python:3.13-slim"}},
+		{name: "intermediate", nodes: []string{"python:3.13"}, expected: []string{"app:1.0"}},
+		{name: "unrelated", nodes: []string{"node:24"}, expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, g.TransitiveDependents(tc.nodes...)); diff != "" {
+				t.Errorf("unexpected dependents (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGraph_Subgraph(t *testing.T) {
+	g := closureGraph()
+	sub := g.Subgraph([]string{"ubuntu:22.04", "python:3.13", "python:3.13-slim"})
+
+	if diff := cmp.Diff([]string{"python:3.13", "python:3.13-slim", "ubuntu:22.04"}, sub.Nodes()); diff != "" {
+		t.Errorf("unexpected nodes (-want +got):\n%s", diff)
+	}
+
+	expected := []Edge{
+		{From: "python:3.13", To: "ubuntu:22.04", Origins: []EdgeOrigin{{Kind: EdgeReference, File: "python/3.13/Dockerfile", Line: 1}}},
+		{From: "python:3.13-slim", To: "ubuntu:22.04"},
+	}
+	if diff := cmp.Diff(expected, sub.Edges()); diff != "" {
+		t.Errorf("unexpected edges (-want +got):\n%s", diff)
+	}
+}
+
+func TestGraph_TopLevel(t *testing.T) {
+	g := closureGraph()
+	g.AddImage("golang:1.25")
+	g.AddImage("golang:1.25-alpine")
+	g.AddDependency("golang:1.25-alpine", "golang:1.25")
+
+	expected := []string{"app:1.0", "golang:1.25-alpine", "node:24", "python:3.13-slim"}
+	if diff := cmp.Diff(expected, g.TopLevel()); diff != "" {
+		t.Errorf("unexpected top-level nodes (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,244 @@
+package dependency
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Edge is a dependency edge, From depends on To.
+type Edge struct {
+	From string
+	To   string
+	// Origins are all declarations of the edge, empty if unknown
+	Origins []EdgeOrigin
+}
+
+// Kinds returns the distinct kinds of the edge origins in the order they were declared.
+func (e Edge) Kinds() []EdgeKind {
+	var kinds []EdgeKind
+	for _, origin := range e.Origins {
+		if !slices.Contains(kinds, origin.Kind) {
+			kinds = append(kinds, origin.Kind)
+		}
+	}
+	return kinds
+}
+
+// label joins the kinds of the edge origins, empty if unknown.
+func (e Edge) label() string {
+	kinds := make([]string, 0, len(e.Origins))
+	for _, kind := range e.Kinds() {
+		kinds = append(kinds, string(kind))
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// dependsOnOnly reports whether the edge is only declared via depends_on and not referenced in a Dockerfile.
+func (e Edge) dependsOnOnly() bool {
+	kinds := e.Kinds()
+	return len(kinds) == 1 && kinds[0] == EdgeDependsOn
+}
+
+// Nodes returns all nodes of the graph sorted by name.
+func (g *Graph) Nodes() []string {
+	return slices.Sorted(maps.Keys(g.nodes))
+}
+
+// Edges returns all distinct edges sorted by From and To, together with their origins if known.
+func (g *Graph) Edges() []Edge {
+	var edges []Edge
+	for _, from := range slices.Sorted(maps.Keys(g.edges)) {
+		for _, to := range slices.Compact(slices.Sorted(slices.Values(g.edges[from]))) {
+			edges = append(edges, Edge{From: from, To: to, Origins: g.Origins(from, to)})
+		}
+	}
+	return edges
+}
+
+// Levels groups the nodes into build levels. Level 0 contains all nodes without dependencies,
+// every other node is placed one level above its highest dependency. Nodes of a level can be built in parallel.
+func (g *Graph) Levels() ([][]string, error) {
+	order, err := g.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	levelOf := make(map[string]int, len(order))
+	var levels [][]string
+	for _, node := range order {
+		level := 0
+		for _, dep := range g.edges[node] {
+			level = max(level, levelOf[dep]+1)
+		}
+		levelOf[node] = level
+		if level == len(levels) {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], node)
+	}
+	for _, level := range levels {
+		slices.Sort(level)
+	}
+	return levels, nil
+}
+
+// ExportOptions controls the graph exports.
+type ExportOptions struct {
+	// Levels groups the nodes into build levels
+	Levels bool
+}
+
+// nodeGroups returns the nodes grouped by level, or a single group if levels are disabled.
+func (g *Graph) nodeGroups(opts ExportOptions) ([][]string, error) {
+	if !opts.Levels {
+		return [][]string{g.Nodes()}, nil
+	}
+	return g.Levels()
+}
+
+// WriteDOT writes the graph in the Graphviz DOT format. Edges only declared via depends_on are dashed.
+func (g *Graph) WriteDOT(w io.Writer, opts ExportOptions) error {
+	groups, err := g.nodeGroups(opts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=BT;\n")
+	b.WriteString("  node [shape=box];\n")
+	for i, nodes := range groups {
+		indent := "  "
+		if opts.Levels {
+			fmt.Fprintf(&b, "  subgraph cluster_level_%d {\n", i)
+			fmt.Fprintf(&b, "    label=%q;\n", fmt.Sprintf("Level %d", i))
+			indent = "    "
+		}
+		for _, node := range nodes {
+			fmt.Fprintf(&b, "%s%q;\n", indent, node)
+		}
+		if opts.Levels {
+			b.WriteString("  }\n")
+		}
+	}
+	for _, e := range g.Edges() {
+		var attrs []string
+		if label := e.label(); label != "" {
+			attrs = append(attrs, fmt.Sprintf("label=%q", label))
+		}
+		if e.dependsOnOnly() {
+			attrs = append(attrs, "style=dashed")
+		}
+		var tooltips []string
+		for _, origin := range e.Origins {
+			if origin.File != "" {
+				tooltips = append(tooltips, origin.String())
+			}
+		}
+		if len(tooltips) > 0 {
+			attrs = append(attrs, fmt.Sprintf("tooltip=%q", strings.Join(tooltips, "\n")))
+		}
+		if len(attrs) == 0 {
+			fmt.Fprintf(&b, "  %q -> %q;\n", e.From, e.To)
+			continue
+		}
+		fmt.Fprintf(&b, "  %q -> %q [%s];\n", e.From, e.To, strings.Join(attrs, ", "))
+	}
+	b.WriteString("}\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes the graph as Mermaid flowchart. Edges only declared via depends_on are dotted.
+// Node names are no valid Mermaid identifiers, so nodes get generated IDs and use their name as label.
+func (g *Graph) WriteMermaid(w io.Writer, opts ExportOptions) error {
+	groups, err := g.nodeGroups(opts)
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]string, len(g.nodes))
+	for i, node := range g.Nodes() {
+		ids[node] = fmt.Sprintf("n%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart BT\n")
+	for i, nodes := range groups {
+		indent := "  "
+		if opts.Levels {
+			fmt.Fprintf(&b, "  subgraph level_%d [\"Level %d\"]\n", i, i)
+			indent = "    "
+		}
+		for _, node := range nodes {
+			fmt.Fprintf(&b, "%s%s[\"%s\"]\n", indent, ids[node], node)
+		}
+		if opts.Levels {
+			b.WriteString("  end\n")
+		}
+	}
+	for _, e := range g.Edges() {
+		arrow := "-->"
+		if e.dependsOnOnly() {
+			arrow = "-.->"
+		}
+		label := e.label()
+		if label == "" {
+			fmt.Fprintf(&b, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+			continue
+		}
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", ids[e.From], arrow, label, ids[e.To])
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+type jsonGraph struct {
+	Nodes  []string   `json:"nodes"`
+	Edges  []jsonEdge `json:"edges"`
+	Levels [][]string `json:"levels,omitempty"`
+}
+
+type jsonEdge struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Origins []jsonOrigin `json:"origins,omitempty"`
+}
+
+type jsonOrigin struct {
+	Kind EdgeKind `json:"kind"`
+	File string   `json:"file,omitempty"`
+	Line int      `json:"line,omitempty"`
+}
+
+// WriteJSON writes the nodes and edges of the graph as JSON, including the build levels if requested.
+func (g *Graph) WriteJSON(w io.Writer, opts ExportOptions) error {
+	out := jsonGraph{
+		Nodes: g.Nodes(),
+		Edges: []jsonEdge{},
+	}
+	for _, e := range g.Edges() {
+		edge := jsonEdge{From: e.From, To: e.To}
+		for _, origin := range e.Origins {
+			edge.Origins = append(edge.Origins, jsonOrigin{Kind: origin.Kind, File: origin.File, Line: origin.Line})
+		}
+		out.Edges = append(out.Edges, edge)
+	}
+	if opts.Levels {
+		levels, err := g.Levels()
+		if err != nil {
+			return err
+		}
+		out.Levels = levels
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
@@ -0,0 +1,245 @@
+package dependency
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func exportGraph() *Graph {
+	g := NewGraph()
+	for _, node := range []string{"app:1.0", "python:3.13", "tools:2", "ubuntu:22.04"} {
+		g.AddImage(node)
+	}
+	g.AddDependencyWithOrigin("python:3.13", "ubuntu:22.04", EdgeOrigin{Kind: EdgeReference, File: "dist/python/3.13/Dockerfile", Line: 1})
+	g.AddDependencyWithOrigin("app:1.0", "python:3.13", EdgeOrigin{Kind: EdgeDependsOn, File: "images/app/image.yml"})
+	g.AddDependencyWithOrigin("app:1.0", "python:3.13", EdgeOrigin{Kind: EdgeReference, File: "dist/app/1.0/Dockerfile", Line: 4})
+	g.AddDependency("app:1.0", "tools:2")
+	return g
+}
+
+func TestGraph_Levels(t *testing.T) {
+	levels, err := exportGraph().Levels()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := [][]string{
+		{"tools:2", "ubuntu:22.04"},
+		{"python:3.13"},
+		{"app:1.0"},
+	}
+	if diff := cmp.Diff(expected, levels); diff != "" {
+		t.Errorf("Levels() mismatch (-expected +got):\n%s", diff)
+	}
+
+	t.Run("returns error for cycle", func(t *testing.T) {
+		g := NewGraph()
+		g.AddImage("A")
+		g.AddImage("B")
+		g.AddDependency("A", "B")
+		g.AddDependency("B", "A")
+		if _, err := g.Levels(); err == nil {
+			t.Fatal("expected cycle error, got nil")
+		}
+	})
+}
+
+func TestGraph_Edges(t *testing.T) {
+	expected := []Edge{
+		{From: "app:1.0", To: "python:3.13", Origins: []EdgeOrigin{
+			{Kind: EdgeDependsOn, File: "images/app/image.yml"},
+			{Kind: EdgeReference, File: "dist/app/1.0/Dockerfile", Line: 4},
+		}},
+		{From: "app:1.0", To: "tools:2"},
+		{From: "python:3.13", To: "ubuntu:22.04", Origins: []EdgeOrigin{{Kind: EdgeReference, File: "dist/python/3.13/Dockerfile", Line: 1}}},
+	}
+	if diff := cmp.Diff(expected, exportGraph().Edges()); diff != "" {
+		t.Errorf("Edges() mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestGraph_Export(t *testing.T) {
+	testCases := []struct {
+		name     string
+		write    func(*Graph, *bytes.Buffer, ExportOptions) error
+		opts     ExportOptions
+		expected string
+	}{
+		{
+			name:  "dot",
+			write: func(g *Graph, b *bytes.Buffer, opts ExportOptions) error { return g.WriteDOT(b, opts) },
+			expected: `digraph dependencies {
+  rankdir=BT;
+  node [shape=box];
+  "app:1.0";
+  "python:3.13";
+  "tools:2";
+  "ubuntu:22.04";
+  "app:1.0" -> "python:3.13" [label="depends_on, reference", tooltip="depends_on in images/app/image.yml\n__hive__/ reference in dist/app/1.0/Dockerfile:4"];
+  "app:1.0" -> "tools:2";
+  "python:3.13" -> "ubuntu:22.04" [label="reference", tooltip="__hive__/ reference in dist/python/3.13/Dockerfile:1"];
+}
+`,
+		},
+		{
+			name:  "dot with levels",
+			write: func(g *Graph, b *bytes.Buffer, opts ExportOptions) error { return g.WriteDOT(b, opts) },
+			opts:  ExportOptions{Levels: true},
+			expected: `digraph dependencies {
+  rankdir=BT;
+  node [shape=box];
+  subgraph cluster_level_0 {
+    label="Level 0";
+    "tools:2";
+    "ubuntu:22.04";
+  }
+  subgraph cluster_level_1 {
+    label="Level 1";
+    "python:3.13";
+  }
+  subgraph cluster_level_2 {
+    label="Level 2";
+    "app:1.0";
+  }
+  "app:1.0" -> "python:3.13" [label="depends_on, reference", tooltip="depends_on in images/app/image.yml\n__hive__/ reference in dist/app/1.0/Dockerfile:4"];
+  "app:1.0" -> "tools:2";
+  "python:3.13" -> "ubuntu:22.04" [label="reference", tooltip="__hive__/ reference in dist/python/3.13/Dockerfile:1"];
+}
+`,
+		},
+		{
+			name:  "mermaid",
+			write: func(g *Graph, b *bytes.Buffer, opts ExportOptions) error { return g.WriteMermaid(b, opts) },
+			expected: `flowchart BT
+  n0["app:1.0"]
+  n1["python:3.13"]
+  n2["tools:2"]
+  n3["ubuntu:22.04"]
+  n0 -->|depends_on, reference| n1
+  n0 --> n2
+  n1 -->|reference| n3
+`,
+		},
+		{
+			name:  "mermaid with levels",
+			write: func(g *Graph, b *bytes.Buffer, opts ExportOptions) error { return g.WriteMermaid(b, opts) },
+			opts:  ExportOptions{Levels: true},
+			expected: `flowchart BT
+  subgraph level_0 ["Level 0"]
+    n2["tools:2"]
+    n3["ubuntu:22.04"]
+  end
+  subgraph level_1 ["Level 1"]
+    n1["python:3.13"]
+  end
+  subgraph level_2 ["Level 2"]
+    n0["app:1.0"]
+  end
+  n0 -->|depends_on, reference| n1
+  n0 --> n2
+  n1 -->|reference| n3
+`,
+		},
+		{
+			name:  "json with levels",
+			write: func(g *Graph, b *bytes.Buffer, opts ExportOptions) error { return g.WriteJSON(b, opts) },
+			opts:  ExportOptions{Levels: true},
+			expected: `{
+  "nodes": [
+    "app:1.0",
+    "python:3.13",
+    "tools:2",
+    "ubuntu:22.04"
+  ],
+  "edges": [
+    {
+      "from": "app:1.0",
+      "to": "python:3.13",
+      "origins": [
+        {
+          "kind": "depends_on",
+          "file": "images/app/image.yml"
+        },
+        {
+          "kind": "reference",
+          "file": "dist/app/1.0/Dockerfile",
+          "line": 4
+        }
+      ]
+    },
+    {
+      "from": "app:1.0",
+      "to": "tools:2"
+    },
+    {
+      "from": "python:3.13",
+      "to": "ubuntu:22.04",
+      "origins": [
+        {
+          "kind": "reference",
+          "file": "dist/python/3.13/Dockerfile",
+          "line": 1
+        }
+      ]
+    }
+  ],
+  "levels": [
+    [
+      "tools:2",
+      "ubuntu:22.04"
+    ],
+    [
+      "python:3.13"
+    ],
+    [
+      "app:1.0"
+    ]
+  ]
+}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := tc.write(exportGraph(), &b, tc.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, b.String()); diff != "" {
+				t.Errorf("export mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEdge_Kinds(t *testing.T) {
+	dependsOn := EdgeOrigin{Kind: EdgeDependsOn, File: "images/app/image.yml"}
+	reference := EdgeOrigin{Kind: EdgeReference, File: "dist/app/1.0/Dockerfile", Line: 4}
+
+	testCases := []struct {
+		name          string
+		origins       []EdgeOrigin
+		expected      []EdgeKind
+		dependsOnOnly bool
+	}{
+		{name: "unknown"},
+		{name: "depends_on", origins: []EdgeOrigin{dependsOn}, expected: []EdgeKind{EdgeDependsOn}, dependsOnOnly: true},
+		{name: "reference", origins: []EdgeOrigin{reference, reference}, expected: []EdgeKind{EdgeReference}},
+		{name: "both", origins: []EdgeOrigin{reference, dependsOn}, expected: []EdgeKind{EdgeReference, EdgeDependsOn}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := Edge{From: "app:1.0", To: "python:3.13", Origins: tc.origins}
+			if diff := cmp.Diff(tc.expected, e.Kinds()); diff != "" {
+				t.Errorf("Kinds() mismatch (-expected +got):\n%s", diff)
+			}
+			if e.dependsOnOnly() != tc.dependsOnOnly {
+				t.Errorf("expected dependsOnOnly() to be %v", tc.dependsOnOnly)
+			}
+		})
+	}
+}
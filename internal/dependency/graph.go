@@ -4,32 +4,99 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
 )
 
+// EdgeKind describes how a dependency edge has been declared.
+type EdgeKind string
+
+const (
+	// EdgeReference is a __hive__/ reference in a rendered Dockerfile.
+	EdgeReference EdgeKind = "reference"
+	// EdgeDependsOn is a depends_on declaration in an image definition.
+	EdgeDependsOn EdgeKind = "depends_on"
+)
+
+// EdgeOrigin points to the declaration that introduced a dependency edge.
+type EdgeOrigin struct {
+	Kind EdgeKind
+	File string
+	// Line is the line of the declaration in File, zero if unknown.
+	Line int
+}
+
+func (o EdgeOrigin) String() string {
+	location := o.File
+	if o.Line > 0 {
+		location = fmt.Sprintf("%s:%d", o.File, o.Line)
+	}
+	switch o.Kind {
+	case EdgeReference:
+		return HivePrefix + " reference in " + location
+	case EdgeDependsOn:
+		return "depends_on in " + location
+	default:
+		return location
+	}
+}
+
+type edgeKey struct {
+	from, to string
+}
+
 // Graph represents a dependency graph of container images.
+// Nodes are image tags and variants keyed by TargetKey, or plain image names.
 // Edges encode "from depends on to", meaning "to" must be built before "from".
 type Graph struct {
-	nodes map[string]bool
-	edges map[string][]string
+	nodes   map[string]bool
+	edges   map[string][]string
+	origins map[edgeKey][]EdgeOrigin
 }
 
 // NewGraph creates an empty dependency graph.
 func NewGraph() *Graph {
 	return &Graph{
-		nodes: make(map[string]bool),
-		edges: make(map[string][]string),
+		nodes:   make(map[string]bool),
+		edges:   make(map[string][]string),
+		origins: make(map[edgeKey][]EdgeOrigin),
 	}
 }
 
-// AddImage registers an image name as a node in the graph.
+// AddImage registers an image, tag or variant as a node in the graph.
 func (g *Graph) AddImage(name string) {
 	g.nodes[name] = true
 }
 
 // AddDependency records that "from" depends on "to",
-// meaning "to" must be built before "from".
+// meaning "to" must be built before "from". Adding an existing edge again has no effect.
 func (g *Graph) AddDependency(from, to string) {
-	g.edges[from] = append(g.edges[from], to)
+	if !slices.Contains(g.edges[from], to) {
+		g.edges[from] = append(g.edges[from], to)
+	}
+}
+
+// AddDependencyWithOrigin records that "from" depends on "to" together with the declaration introducing it.
+// If the edge is declared multiple times, e.g. via depends_on and a __hive__/ reference, all origins are kept.
+func (g *Graph) AddDependencyWithOrigin(from, to string, origin EdgeOrigin) {
+	g.AddDependency(from, to)
+	key := edgeKey{from, to}
+	if !slices.Contains(g.origins[key], origin) {
+		g.origins[key] = append(g.origins[key], origin)
+	}
+}
+
+// Origin returns the first declaration that introduced the edge from "from" to "to", if it is known.
+func (g *Graph) Origin(from, to string) (EdgeOrigin, bool) {
+	origins := g.Origins(from, to)
+	if len(origins) == 0 {
+		return EdgeOrigin{}, false
+	}
+	return origins[0], true
+}
+
+// Origins returns all declarations of the edge from "from" to "to" in the order they were added.
+func (g *Graph) Origins(from, to string) []EdgeOrigin {
+	return g.origins[edgeKey{from, to}]
 }
 
 // Dependencies returns the list of images that the given image depends on.
@@ -107,11 +174,72 @@ func (g *Graph) TopologicalSort() ([]string, error) {
 	}
 
 	if len(order) != len(g.nodes) {
-		return nil, errors.Join(
+		return nil, g.cycleError(order)
+	}
+
+	return order, nil
+}
+
+// cycleError describes a cycle among the nodes that could not be ordered, including the origin of each edge.
+func (g *Graph) cycleError(order []string) error {
+	cycle := g.findCycle(order)
+	if cycle == nil {
+		return errors.Join(
 			errors.New("dependency cycle detected"),
 			fmt.Errorf("resolved %d of %d images", len(order), len(g.nodes)),
 		)
 	}
 
-	return order, nil
+	errs := []error{fmt.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))}
+	for i := 0; i < len(cycle)-1; i++ {
+		from, to := cycle[i], cycle[i+1]
+		if origin, ok := g.Origin(from, to); ok {
+			errs = append(errs, fmt.Errorf("%s -> %s: %s", from, to, origin))
+		} else {
+			errs = append(errs, fmt.Errorf("%s -> %s", from, to))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// findCycle returns a cycle among the unordered nodes, starting and ending with the same node.
+// Every unordered node has at least one unordered dependency, so following them always ends in a cycle.
+func (g *Graph) findCycle(order []string) []string {
+	ordered := make(map[string]bool, len(order))
+	for _, node := range order {
+		ordered[node] = true
+	}
+
+	var remaining []string
+	for node := range g.nodes {
+		if !ordered[node] {
+			remaining = append(remaining, node)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+	slices.Sort(remaining)
+
+	visited := make(map[string]int)
+	var path []string
+	node := remaining[0]
+	for {
+		if idx, seen := visited[node]; seen {
+			return append(path[idx:], node)
+		}
+		visited[node] = len(path)
+		path = append(path, node)
+
+		var next []string
+		for _, dep := range g.edges[node] {
+			if g.nodes[dep] && !ordered[dep] {
+				next = append(next, dep)
+			}
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		node = slices.Min(next)
+	}
 }
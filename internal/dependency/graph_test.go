@@ -1,7 +1,10 @@
 package dependency
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestGraph_TopologicalSort(t *testing.T) {
@@ -108,6 +111,37 @@ func TestGraph_TopologicalSort(t *testing.T) {
 		if err == nil {
 			t.Fatal("expected cycle error, got nil")
 		}
+		if !strings.Contains(err.Error(), "dependency cycle detected: A -> B -> C -> A") {
+			t.Errorf("expected cycle path in error, got %v", err)
+		}
+	})
+
+	t.Run("reports cycle path with edge origins", func(t *testing.T) {
+		g := NewGraph()
+		for _, node := range []string{"app", "base", "python", "tool"} {
+			g.AddImage(node)
+		}
+		// tool is blocked by the cycle but not part of it
+		g.AddDependency("app", "tool")
+		g.AddDependency("tool", "python")
+		g.AddDependencyWithOrigin("python", "app", EdgeOrigin{Kind: EdgeDependsOn, File: "images/python/image.yml"})
+		g.AddDependencyWithOrigin("app", "base", EdgeOrigin{Kind: EdgeReference, File: "dist/app/1/Dockerfile", Line: 3})
+		g.AddDependencyWithOrigin("base", "python", EdgeOrigin{Kind: EdgeReference, File: "dist/base/1/Dockerfile", Line: 1})
+
+		_, err := g.TopologicalSort()
+		if err == nil {
+			t.Fatal("expected cycle error, got nil")
+		}
+
+		expected := strings.Join([]string{
+			"dependency cycle detected: app -> base -> python -> app",
+			"app -> base: __hive__/ reference in dist/app/1/Dockerfile:3",
+			"base -> python: __hive__/ reference in dist/base/1/Dockerfile:1",
+			"python -> app: depends_on in images/python/image.yml",
+		}, "\n")
+		if diff := cmp.Diff(expected, err.Error()); diff != "" {
+			t.Errorf("error mismatch (-expected +got):\n%s", diff)
+		}
 	})
 }
 
@@ -30,7 +30,7 @@ func TestIntegration_DependencyProject(t *testing.T) {
 		t.Fatalf("rendering failed: %v", err)
 	}
 
-	scannedGraph, err := ScanRenderedProject(distPath)
+	scannedGraph, err := ScanRenderedProject(distPath, nil)
 	if err != nil {
 		t.Fatalf("scanning failed: %v", err)
 	}
@@ -58,7 +58,7 @@ func TestIntegration_DependencyProject(t *testing.T) {
 		return -1
 	}
 
-	if indexOf("ubuntu") > indexOf("python") {
-		t.Errorf("ubuntu (idx=%d) must come before python (idx=%d)", indexOf("ubuntu"), indexOf("python"))
+	if indexOf("ubuntu:22.04") == -1 || indexOf("ubuntu:22.04") > indexOf("python:3.13") {
+		t.Errorf("ubuntu:22.04 (idx=%d) must come before python:3.13 (idx=%d)", indexOf("ubuntu:22.04"), indexOf("python:3.13"))
 	}
 }
@@ -2,31 +2,67 @@ package dependency
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/timo-reymann/ContainerHive/pkg/model"
 )
 
-// BuildDependencyGraph merges a scanned dependency graph (from Dockerfile analysis)
-// with explicit depends_on declarations from image configs.
+// imageTargetKeys returns the node keys of all tags and variants of the image.
+func imageTargetKeys(image *model.Image) []string {
+	var keys []string
+	for tagName := range image.Tags {
+		keys = append(keys, TargetKey(image.Name, tagName))
+		for _, variant := range image.Variants {
+			keys = append(keys, TargetKey(image.Name, tagName+variant.TagSuffix))
+		}
+	}
+	return keys
+}
+
+// BuildDependencyGraph creates the build graph of all tags and variants of the project, keyed by TargetKey.
+// Edges from the scanned dependency graph (from Dockerfile analysis) only require the exact referenced tag or variant.
+// Explicit depends_on declarations from image configs make every tag and variant of the image depend on
+// all tags and variants of the declared image.
 func BuildDependencyGraph(scannedGraph *Graph, project *model.ContainerHiveProject) (*Graph, error) {
 	graph := NewGraph()
-	for node := range scannedGraph.nodes {
-		graph.AddImage(node)
+	keysByName := make(map[string][]string)
+	for name, images := range project.ImagesByName {
+		for _, img := range images {
+			for _, key := range imageTargetKeys(img) {
+				graph.AddImage(key)
+				keysByName[name] = append(keysByName[name], key)
+			}
+		}
 	}
-	for from, deps := range scannedGraph.edges {
-		for _, dep := range deps {
+
+	for _, from := range slices.Sorted(maps.Keys(scannedGraph.edges)) {
+		// Skip leftovers of tags that have been removed from the project since the last render
+		if !graph.nodes[from] {
+			continue
+		}
+		for _, dep := range scannedGraph.edges[from] {
+			if !graph.nodes[dep] {
+				return nil, fmt.Errorf("%q references %q, but no tag or variant with that name exists in the project", from, HivePrefix+dep)
+			}
 			graph.AddDependency(from, dep)
+			for _, origin := range scannedGraph.Origins(from, dep) {
+				graph.AddDependencyWithOrigin(from, dep, origin)
+			}
 		}
 	}
 
 	for name, images := range project.ImagesByName {
-		graph.AddImage(name)
 		for _, img := range images {
 			for _, dep := range img.DependsOn {
 				if _, exists := project.ImagesByName[dep]; !exists {
 					return nil, fmt.Errorf("image %q declares depends_on %q, but no image with that name exists in the project", name, dep)
 				}
-				graph.AddDependency(name, dep)
+				for _, from := range imageTargetKeys(img) {
+					for _, to := range keysByName[dep] {
+						graph.AddDependencyWithOrigin(from, to, EdgeOrigin{Kind: EdgeDependsOn, File: img.DefinitionFilePath})
+					}
+				}
 			}
 		}
 	}
@@ -1,24 +1,73 @@
 package dependency
 
 import (
+	"slices"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/timo-reymann/ContainerHive/pkg/model"
 )
 
+func tags(names ...string) map[string]*model.Tag {
+	result := make(map[string]*model.Tag, len(names))
+	for _, name := range names {
+		result[name] = &model.Tag{Name: name}
+	}
+	return result
+}
+
+func sortedDependencies(graph *Graph, key string) []string {
+	return slices.Compact(slices.Sorted(slices.Values(graph.Dependencies(key))))
+}
+
 func TestBuildDependencyGraph(t *testing.T) {
 	t.Run("merges auto-detected and explicit dependencies", func(t *testing.T) {
 		scannedGraph := NewGraph()
-		scannedGraph.AddImage("ubuntu")
-		scannedGraph.AddImage("python")
-		scannedGraph.AddImage("app")
-		scannedGraph.AddDependency("python", "ubuntu")
+		scannedGraph.AddImage("ubuntu:22.04")
+		scannedGraph.AddImage("python:3.13")
+		scannedGraph.AddImage("app:latest")
+		scannedGraph.AddDependency("python:3.13", "ubuntu:22.04")
+
+		project := &model.ContainerHiveProject{
+			ImagesByName: map[string][]*model.Image{
+				"ubuntu": {{Name: "ubuntu", Tags: tags("22.04")}},
+				"python": {{Name: "python", Tags: tags("3.13"), DependsOn: []string{"ubuntu"}}},
+				"app":    {{Name: "app", Tags: tags("latest"), DependsOn: []string{"python"}}},
+			},
+		}
+
+		graph, err := BuildDependencyGraph(scannedGraph, project)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		order, err := graph.TopologicalSort()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if diff := cmp.Diff([]string{"ubuntu:22.04", "python:3.13", "app:latest"}, order); diff != "" {
+			t.Errorf("order mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("references only require the referenced tag or variant", func(t *testing.T) {
+		scannedGraph := NewGraph()
+		scannedGraph.AddDependency("python:3.13", "ubuntu:22.04")
+		scannedGraph.AddDependency("python:3.13-slim", "ubuntu:24.04-minimal")
 
 		project := &model.ContainerHiveProject{
 			ImagesByName: map[string][]*model.Image{
-				"ubuntu": {{Name: "ubuntu"}},
-				"python": {{Name: "python", DependsOn: []string{"ubuntu"}}},
-				"app":    {{Name: "app", DependsOn: []string{"python"}}},
+				"ubuntu": {{
+					Name:     "ubuntu",
+					Tags:     tags("22.04", "24.04"),
+					Variants: map[string]*model.ImageVariant{"minimal": {Name: "minimal", TagSuffix: "-minimal"}},
+				}},
+				"python": {{
+					Name:     "python",
+					Tags:     tags("3.13"),
+					Variants: map[string]*model.ImageVariant{"slim": {Name: "slim", TagSuffix: "-slim"}},
+				}},
 			},
 		}
 
@@ -31,31 +80,82 @@ func TestBuildDependencyGraph(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
+		if len(order) != 6 {
+			t.Errorf("expected all 6 tags and variants to be nodes, got %v", order)
+		}
+
+		if diff := cmp.Diff([]string{"ubuntu:22.04"}, sortedDependencies(graph, "python:3.13")); diff != "" {
+			t.Errorf("python:3.13 dependencies mismatch (-expected +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"ubuntu:24.04-minimal"}, sortedDependencies(graph, "python:3.13-slim")); diff != "" {
+			t.Errorf("python:3.13-slim dependencies mismatch (-expected +got):\n%s", diff)
+		}
+		if deps := graph.Dependents("ubuntu:24.04"); len(deps) != 0 {
+			t.Errorf("expected no dependents of ubuntu:24.04, got %v", deps)
+		}
+	})
+
+	t.Run("depends_on requires all tags and variants", func(t *testing.T) {
+		project := &model.ContainerHiveProject{
+			ImagesByName: map[string][]*model.Image{
+				"ubuntu": {{Name: "ubuntu", Tags: tags("22.04", "24.04")}},
+				"python": {{Name: "python", Tags: tags("3.13"), DependsOn: []string{"ubuntu"}}},
+			},
+		}
+
+		graph, err := BuildDependencyGraph(NewGraph(), project)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if diff := cmp.Diff([]string{"ubuntu:22.04", "ubuntu:24.04"}, sortedDependencies(graph, "python:3.13")); diff != "" {
+			t.Errorf("dependencies mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("ignores rendered tags that are no longer part of the project", func(t *testing.T) {
+		scannedGraph := NewGraph()
+		scannedGraph.AddDependency("python:3.12", "ubuntu:20.04")
 
-		indexOf := func(name string) int {
-			for i, v := range order {
-				if v == name {
-					return i
-				}
-			}
-			return -1
+		project := &model.ContainerHiveProject{
+			ImagesByName: map[string][]*model.Image{
+				"ubuntu": {{Name: "ubuntu", Tags: tags("22.04")}},
+				"python": {{Name: "python", Tags: tags("3.13")}},
+			},
 		}
 
-		if indexOf("ubuntu") > indexOf("python") {
-			t.Error("ubuntu must come before python")
+		graph, err := BuildDependencyGraph(scannedGraph, project)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if graph.HasDependencies() {
+			t.Error("expected stale references to be ignored")
+		}
+	})
+
+	t.Run("errors on unknown referenced tag", func(t *testing.T) {
+		scannedGraph := NewGraph()
+		scannedGraph.AddDependency("python:3.13", "ubuntu:99.04")
+
+		project := &model.ContainerHiveProject{
+			ImagesByName: map[string][]*model.Image{
+				"ubuntu": {{Name: "ubuntu", Tags: tags("22.04")}},
+				"python": {{Name: "python", Tags: tags("3.13")}},
+			},
 		}
-		if indexOf("python") > indexOf("app") {
-			t.Error("python must come before app")
+
+		if _, err := BuildDependencyGraph(scannedGraph, project); err == nil {
+			t.Fatal("expected error for unknown reference, got nil")
 		}
 	})
 
 	t.Run("errors on unknown depends_on target", func(t *testing.T) {
 		scannedGraph := NewGraph()
-		scannedGraph.AddImage("app")
+		scannedGraph.AddImage("app:latest")
 
 		project := &model.ContainerHiveProject{
 			ImagesByName: map[string][]*model.Image{
-				"app": {{Name: "app", DependsOn: []string{"nonexistent"}}},
+				"app": {{Name: "app", Tags: tags("latest"), DependsOn: []string{"nonexistent"}}},
 			},
 		}
 
@@ -64,4 +164,31 @@ func TestBuildDependencyGraph(t *testing.T) {
 			t.Fatal("expected error for unknown dependency, got nil")
 		}
 	})
+
+	t.Run("keeps edge origins", func(t *testing.T) {
+		scannedGraph := NewGraph()
+		scannedGraph.AddDependencyWithOrigin("python:3.13", "ubuntu:22.04", EdgeOrigin{Kind: EdgeReference, File: "dist/python/3.13/Dockerfile", Line: 2})
+
+		project := &model.ContainerHiveProject{
+			ImagesByName: map[string][]*model.Image{
+				"ubuntu": {{Name: "ubuntu", Tags: tags("22.04")}},
+				"python": {{Name: "python", Tags: tags("3.13")}},
+				"app":    {{Name: "app", Tags: tags("1.0"), DefinitionFilePath: "images/app/image.yml", DependsOn: []string{"python"}}},
+			},
+		}
+
+		graph, err := BuildDependencyGraph(scannedGraph, project)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		origin, _ := graph.Origin("python:3.13", "ubuntu:22.04")
+		if diff := cmp.Diff(EdgeOrigin{Kind: EdgeReference, File: "dist/python/3.13/Dockerfile", Line: 2}, origin); diff != "" {
+			t.Errorf("reference origin mismatch (-expected +got):\n%s", diff)
+		}
+		origin, _ = graph.Origin("app:1.0", "python:3.13")
+		if diff := cmp.Diff(EdgeOrigin{Kind: EdgeDependsOn, File: "images/app/image.yml"}, origin); diff != "" {
+			t.Errorf("depends_on origin mismatch (-expected +got):\n%s", diff)
+		}
+	})
 }
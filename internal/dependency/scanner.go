@@ -2,46 +2,257 @@ package dependency
 
 import (
 	"errors"
+	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/instructions"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/moby/buildkit/frontend/dockerfile/shell"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
 )
 
 const HivePrefix = "__hive__/"
 
-var hiveFromPattern = regexp.MustCompile(`(?i)^FROM\s+__hive__/([^:\s]+):([^\s]+)`)
-
 // HiveRef represents a reference to a project-local image via the __hive__/ prefix.
 type HiveRef struct {
 	ImageName string
 	Tag       string
+	// Line is the line of the Dockerfile instruction containing the reference.
+	Line int
+}
+
+// Key returns the graph node key of the referenced tag or variant.
+func (r HiveRef) Key() string {
+	return TargetKey(r.ImageName, r.Tag)
+}
+
+// TargetKey returns the graph node key of a tag or variant in the form name:tag,
+// where the tag of a variant includes its tag suffix.
+func TargetKey(imageName, tag string) string {
+	return imageName + ":" + tag
 }
 
-// ScanDockerfileForHiveRefs scans a Dockerfile for FROM __hive__/<name>:<tag> references.
-func ScanDockerfileForHiveRefs(dockerfilePath string) ([]HiveRef, error) {
-	content, err := os.ReadFile(dockerfilePath)
+// argEnv holds the ARG and ENV values visible to an instruction during expansion.
+type argEnv map[string]string
+
+func (e argEnv) Get(key string) (string, bool) {
+	v, ok := e[key]
+	return v, ok
+}
+
+func (e argEnv) Keys() []string {
+	return slices.Collect(maps.Keys(e))
+}
+
+// dockerfileScanner expands and collects __hive__/ references of a parsed Dockerfile.
+type dockerfileScanner struct {
+	lex       *shell.Lex
+	buildArgs model.BuildArgs
+	refs      []HiveRef
+}
+
+// ScanDockerfileForHiveRefs scans a Dockerfile for __hive__/<name>:<tag> references.
+// References are detected in FROM instructions, COPY --from and RUN --mount=from=.
+// ARG values are expanded like buildkit does, with the build args taking precedence over ARG defaults.
+// A reference without tag, e.g. because an ARG is not set, or with a digest is reported as an error instead of being skipped.
+func ScanDockerfileForHiveRefs(dockerfilePath string, buildArgs model.BuildArgs) ([]HiveRef, error) {
+	s, stages, metaEnv, err := parseDockerfile(dockerfilePath, buildArgs)
 	if err != nil {
 		return nil, err
 	}
 
-	var refs []HiveRef
-	for _, line := range strings.Split(string(content), "\n") {
-		line = strings.TrimSpace(line)
-		matches := hiveFromPattern.FindStringSubmatch(line)
-		if matches != nil {
-			refs = append(refs, HiveRef{
-				ImageName: matches[1],
-				Tag:       matches[2],
-			})
+	for _, stage := range stages {
+		if err := s.collect(stage.BaseName, metaEnv, stage.Location); err != nil {
+			return nil, err
+		}
+
+		env := argEnv{}
+		for _, cmd := range stage.Commands {
+			if err := s.scanCommand(cmd, env, metaEnv); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s.refs, nil
+}
+
+// ScanDockerfileForBaseRef returns the __hive__/ reference the final stage of a Dockerfile is based on,
+// following stages that are based on previous stages. Returns nil if the final stage is not based on a project image.
+func ScanDockerfileForBaseRef(dockerfilePath string, buildArgs model.BuildArgs) (*HiveRef, error) {
+	s, stages, metaEnv, err := parseDockerfile(dockerfilePath, buildArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	bases := make([]*HiveRef, len(stages))
+	for i, stage := range stages {
+		baseName, err := s.expand(stage.BaseName, metaEnv, stage.Location)
+		if err != nil {
+			return nil, err
+		}
+		if previous := slices.IndexFunc(stages[:i], func(st instructions.Stage) bool {
+			return st.Name != "" && strings.EqualFold(st.Name, baseName)
+		}); previous >= 0 {
+			bases[i] = bases[previous]
+			continue
+		}
+
+		before := len(s.refs)
+		if err := s.collect(baseName, metaEnv, stage.Location); err != nil {
+			return nil, err
+		}
+		if len(s.refs) > before {
+			ref := s.refs[before]
+			bases[i] = &ref
+		}
+	}
+
+	if len(bases) == 0 {
+		return nil, nil
+	}
+	return bases[len(bases)-1], nil
+}
+
+// parseDockerfile parses the Dockerfile and returns a scanner, its stages and the values of the global ARGs.
+func parseDockerfile(dockerfilePath string, buildArgs model.BuildArgs) (*dockerfileScanner, []instructions.Stage, argEnv, error) {
+	f, err := os.Open(dockerfilePath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer f.Close()
+
+	result, err := parser.Parse(f)
+	if err != nil {
+		return nil, nil, nil, errors.Join(errors.New("failed to parse Dockerfile"), err)
+	}
+
+	stages, metaArgs, err := instructions.Parse(result.AST, nil)
+	if err != nil {
+		return nil, nil, nil, errors.Join(errors.New("failed to parse Dockerfile instructions"), err)
+	}
+
+	s := &dockerfileScanner{
+		lex:       shell.NewLex(result.EscapeToken),
+		buildArgs: buildArgs,
+	}
+
+	metaEnv := argEnv{}
+	for _, arg := range metaArgs {
+		if err := s.declareArgs(metaEnv, nil, &arg); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return s, stages, metaEnv, nil
+}
+
+// scanCommand collects references of a stage instruction and tracks the ARG and ENV values it declares.
+func (s *dockerfileScanner) scanCommand(cmd instructions.Command, env, metaEnv argEnv) error {
+	switch c := cmd.(type) {
+	case *instructions.ArgCommand:
+		return s.declareArgs(env, metaEnv, c)
+	case *instructions.EnvCommand:
+		for _, kv := range c.Env {
+			value, err := s.expand(kv.Value, env, c.Location())
+			if err != nil {
+				return err
+			}
+			env[kv.Key] = value
+		}
+	case *instructions.CopyCommand:
+		return s.collect(c.From, env, c.Location())
+	case *instructions.RunCommand:
+		for _, mount := range instructions.GetMounts(c) {
+			if err := s.collect(mount.From, env, c.Location()); err != nil {
+				return err
+			}
 		}
 	}
-	return refs, nil
+	return nil
+}
+
+// declareArgs sets the values of an ARG instruction. Build args take precedence over defaults,
+// stage ARGs without default inherit the value of the global ARG with the same name.
+func (s *dockerfileScanner) declareArgs(env, metaEnv argEnv, cmd *instructions.ArgCommand) error {
+	for _, arg := range cmd.Args {
+		if value, ok := s.buildArgs[arg.Key]; ok {
+			env[arg.Key] = value
+			continue
+		}
+		if arg.Value != nil {
+			value, err := s.expand(*arg.Value, env, cmd.Location())
+			if err != nil {
+				return err
+			}
+			env[arg.Key] = value
+			continue
+		}
+		if value, ok := metaEnv[arg.Key]; ok {
+			env[arg.Key] = value
+		}
+	}
+	return nil
+}
+
+func (s *dockerfileScanner) expand(word string, env argEnv, location []parser.Range) (string, error) {
+	expanded, _, err := s.lex.ProcessWord(word, env)
+	if err != nil {
+		return "", fmt.Errorf("line %d: failed to expand %q: %w", line(location), word, err)
+	}
+	return expanded, nil
+}
+
+// collect expands the image reference and records it if it points to a project image.
+func (s *dockerfileScanner) collect(ref string, env argEnv, location []parser.Range) error {
+	if ref == "" {
+		return nil
+	}
+
+	expanded, err := s.expand(ref, env, location)
+	if err != nil {
+		return err
+	}
+
+	rest, ok := strings.CutPrefix(expanded, HivePrefix)
+	if !ok {
+		return nil
+	}
+
+	// Project images are built in the same run, so there is no digest to pin them to yet
+	if strings.Contains(rest, "@") {
+		return fmt.Errorf("line %d: digest in hive reference %q is not supported, expected %s<name>:<tag>", line(location), expanded, HivePrefix)
+	}
+
+	imageName, tag, _ := strings.Cut(rest, ":")
+	if imageName == "" || tag == "" {
+		return fmt.Errorf("line %d: incomplete hive reference %q, expected %s<name>:<tag>", line(location), expanded, HivePrefix)
+	}
+
+	s.refs = append(s.refs, HiveRef{
+		ImageName: imageName,
+		Tag:       tag,
+		Line:      line(location),
+	})
+	return nil
+}
+
+// line returns the first line of an instruction location.
+func line(location []parser.Range) int {
+	if len(location) == 0 {
+		return 0
+	}
+	return location[0].Start.Line
 }
 
 // ScanRenderedProject scans all Dockerfiles in a rendered dist directory
 // and builds a dependency graph based on __hive__/ references.
-func ScanRenderedProject(distPath string) (*Graph, error) {
+// Nodes are the rendered tags and variants keyed by TargetKey, edges point to the exact referenced tag.
+// The build args of each target, keyed by TargetKey, are used to expand ARGs in the Dockerfiles.
+func ScanRenderedProject(distPath string, buildArgs map[string]model.BuildArgs) (*Graph, error) {
 	graph := NewGraph()
 
 	entries, err := os.ReadDir(distPath)
@@ -49,13 +260,6 @@ func ScanRenderedProject(distPath string) (*Graph, error) {
 		return nil, errors.Join(errors.New("failed to read dist directory"), err)
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-		graph.AddImage(entry.Name())
-	}
-
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
@@ -73,6 +277,8 @@ func ScanRenderedProject(distPath string) (*Graph, error) {
 				continue
 			}
 			tagDir := filepath.Join(imageDir, tagEntry.Name())
+			key := TargetKey(imageName, tagEntry.Name())
+			graph.AddImage(key)
 
 			for _, dfName := range []string{"Dockerfile", "Dockerfile.gotpl"} {
 				dfPath := filepath.Join(tagDir, dfName)
@@ -80,13 +286,13 @@ func ScanRenderedProject(distPath string) (*Graph, error) {
 					continue
 				}
 
-				refs, err := ScanDockerfileForHiveRefs(dfPath)
+				refs, err := ScanDockerfileForHiveRefs(dfPath, buildArgs[key])
 				if err != nil {
 					return nil, errors.Join(errors.New("failed to scan "+dfPath), err)
 				}
 
 				for _, ref := range refs {
-					graph.AddDependency(imageName, ref.ImageName)
+					graph.AddDependencyWithOrigin(key, ref.Key(), EdgeOrigin{Kind: EdgeReference, File: dfPath, Line: ref.Line})
 				}
 			}
 		}
@@ -3,7 +3,11 @@ package dependency
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
 )
 
 func TestScanDockerfileForHiveRefs(t *testing.T) {
@@ -12,7 +16,7 @@ func TestScanDockerfileForHiveRefs(t *testing.T) {
 		df := filepath.Join(dir, "Dockerfile")
 		os.WriteFile(df, []byte("FROM __hive__/ubuntu:22.04\nRUN echo hello"), 0644)
 
-		refs, err := ScanDockerfileForHiveRefs(df)
+		refs, err := ScanDockerfileForHiveRefs(df, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -30,7 +34,7 @@ func TestScanDockerfileForHiveRefs(t *testing.T) {
 		content := "FROM __hive__/ubuntu:22.04 AS base\nFROM __hive__/node:20\nRUN echo hello"
 		os.WriteFile(df, []byte(content), 0644)
 
-		refs, err := ScanDockerfileForHiveRefs(df)
+		refs, err := ScanDockerfileForHiveRefs(df, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -44,7 +48,7 @@ func TestScanDockerfileForHiveRefs(t *testing.T) {
 		df := filepath.Join(dir, "Dockerfile")
 		os.WriteFile(df, []byte("FROM ubuntu:22.04\nRUN echo hello"), 0644)
 
-		refs, err := ScanDockerfileForHiveRefs(df)
+		refs, err := ScanDockerfileForHiveRefs(df, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -58,7 +62,7 @@ func TestScanDockerfileForHiveRefs(t *testing.T) {
 		df := filepath.Join(dir, "Dockerfile")
 		os.WriteFile(df, []byte("FROM __hive__/ubuntu:22.04 AS builder"), 0644)
 
-		refs, err := ScanDockerfileForHiveRefs(df)
+		refs, err := ScanDockerfileForHiveRefs(df, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -71,6 +75,167 @@ func TestScanDockerfileForHiveRefs(t *testing.T) {
 	})
 }
 
+func TestScanDockerfileForHiveRefs_Instructions(t *testing.T) {
+	testCases := []struct {
+		name       string
+		dockerfile string
+		buildArgs  model.BuildArgs
+		expected   []HiveRef
+	}{
+		{
+			name:       "FROM with platform",
+			dockerfile: "FROM --platform=$BUILDPLATFORM __hive__/ubuntu:22.04 AS base\n",
+			expected:   []HiveRef{{ImageName: "ubuntu", Tag: "22.04", Line: 1}},
+		},
+		{
+			name:       "COPY from hive image",
+			dockerfile: "FROM alpine:3\nCOPY --from=__hive__/tools:1.0 /bin/tool /bin/tool\n",
+			expected:   []HiveRef{{ImageName: "tools", Tag: "1.0", Line: 2}},
+		},
+		{
+			name:       "RUN mount from hive image",
+			dockerfile: "FROM alpine:3\nRUN --mount=type=bind,from=__hive__/tools:1.0-slim,target=/tools ls /tools\n",
+			expected:   []HiveRef{{ImageName: "tools", Tag: "1.0-slim", Line: 2}},
+		},
+		{
+			name:       "line continuation",
+			dockerfile: "# syntax=docker/dockerfile:1\nFROM \\\n  __hive__/ubuntu:22.04\n",
+			expected:   []HiveRef{{ImageName: "ubuntu", Tag: "22.04", Line: 2}},
+		},
+		{
+			name:       "global ARG default",
+			dockerfile: "ARG BASE_TAG=22.04\nFROM __hive__/ubuntu:${BASE_TAG}\n",
+			expected:   []HiveRef{{ImageName: "ubuntu", Tag: "22.04", Line: 2}},
+		},
+		{
+			name:       "build arg overrides ARG default",
+			dockerfile: "ARG BASE_TAG=22.04\nFROM __hive__/ubuntu:${BASE_TAG}\n",
+			buildArgs:  model.BuildArgs{"BASE_TAG": "24.04"},
+			expected:   []HiveRef{{ImageName: "ubuntu", Tag: "24.04", Line: 2}},
+		},
+		{
+			name:       "stage ARG inherits global value",
+			dockerfile: "ARG TOOLS_VERSION\nFROM alpine:3\nARG TOOLS_VERSION\nCOPY --from=__hive__/tools:${TOOLS_VERSION} /bin/tool /bin/tool\n",
+			buildArgs:  model.BuildArgs{"TOOLS_VERSION": "2.0"},
+			expected:   []HiveRef{{ImageName: "tools", Tag: "2.0", Line: 4}},
+		},
+		{
+			name:       "stage ENV",
+			dockerfile: "FROM alpine:3\nENV TOOLS=__hive__/tools:1.0\nCOPY --from=$TOOLS /bin/tool /bin/tool\n",
+			expected:   []HiveRef{{ImageName: "tools", Tag: "1.0", Line: 3}},
+		},
+		{
+			name:       "multiple stages",
+			dockerfile: "FROM __hive__/ubuntu:22.04 AS base\nFROM __hive__/node:20\nCOPY --from=base /app /app\n",
+			expected: []HiveRef{
+				{ImageName: "ubuntu", Tag: "22.04", Line: 1},
+				{ImageName: "node", Tag: "20", Line: 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			df := filepath.Join(t.TempDir(), "Dockerfile")
+			if err := os.WriteFile(df, []byte(tc.dockerfile), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			refs, err := ScanDockerfileForHiveRefs(df, tc.buildArgs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, refs); diff != "" {
+				t.Errorf("ScanDockerfileForHiveRefs() mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("reports reference with unset ARG", func(t *testing.T) {
+		df := filepath.Join(t.TempDir(), "Dockerfile")
+		if err := os.WriteFile(df, []byte("ARG BASE_TAG\nFROM __hive__/ubuntu:${BASE_TAG}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := ScanDockerfileForHiveRefs(df, nil)
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("expected incomplete reference error for line 2, got %v", err)
+		}
+	})
+
+	t.Run("rejects digest references", func(t *testing.T) {
+		for _, ref := range []string{"__hive__/ubuntu@sha256:abc", "__hive__/ubuntu:22.04@sha256:abc"} {
+			df := filepath.Join(t.TempDir(), "Dockerfile")
+			if err := os.WriteFile(df, []byte("FROM alpine\nCOPY --from="+ref+" /etc /etc\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := ScanDockerfileForHiveRefs(df, nil)
+			if err == nil || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), "digest") {
+				t.Errorf("expected digest error for line 2 of %s, got %v", ref, err)
+			}
+		}
+	})
+
+	t.Run("returns error for invalid Dockerfile", func(t *testing.T) {
+		df := filepath.Join(t.TempDir(), "Dockerfile")
+		if err := os.WriteFile(df, []byte("RUN echo missing from\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ScanDockerfileForHiveRefs(df, nil); err == nil {
+			t.Error("expected error for Dockerfile without FROM")
+		}
+	})
+}
+
+func TestScanDockerfileForBaseRef(t *testing.T) {
+	testCases := []struct {
+		name       string
+		dockerfile string
+		buildArgs  model.BuildArgs
+		expected   *HiveRef
+	}{
+		{
+			name:       "single stage",
+			dockerfile: "FROM __hive__/ubuntu:22.04\n",
+			expected:   &HiveRef{ImageName: "ubuntu", Tag: "22.04", Line: 1},
+		},
+		{
+			name:       "external base",
+			dockerfile: "FROM __hive__/tools:1.0 AS tools\nFROM alpine:3\nCOPY --from=tools /bin/tool /bin/tool\n",
+		},
+		{
+			name:       "final stage based on previous stage",
+			dockerfile: "FROM __hive__/ubuntu:22.04 AS base\nFROM golang:1.25 AS build\nFROM BASE\nCOPY --from=build /app /app\n",
+			expected:   &HiveRef{ImageName: "ubuntu", Tag: "22.04", Line: 1},
+		},
+		{
+			name:       "expanded build arg",
+			dockerfile: "ARG BASE_TAG=22.04\nFROM __hive__/ubuntu:${BASE_TAG}\n",
+			buildArgs:  model.BuildArgs{"BASE_TAG": "24.04"},
+			expected:   &HiveRef{ImageName: "ubuntu", Tag: "24.04", Line: 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			df := filepath.Join(t.TempDir(), "Dockerfile")
+			if err := os.WriteFile(df, []byte(tc.dockerfile), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			ref, err := ScanDockerfileForBaseRef(df, tc.buildArgs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, ref); diff != "" {
+				t.Errorf("ScanDockerfileForBaseRef() mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestScanRenderedProject(t *testing.T) {
 	t.Run("builds graph from rendered dist directory", func(t *testing.T) {
 		dir := t.TempDir()
@@ -81,7 +246,7 @@ func TestScanRenderedProject(t *testing.T) {
 		os.MkdirAll(filepath.Join(dir, "python", "3.13"), 0755)
 		os.WriteFile(filepath.Join(dir, "python", "3.13", "Dockerfile"), []byte("FROM __hive__/ubuntu:22.04"), 0644)
 
-		graph, err := ScanRenderedProject(dir)
+		graph, err := ScanRenderedProject(dir, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -97,25 +262,74 @@ func TestScanRenderedProject(t *testing.T) {
 
 		ubuntuIdx, pythonIdx := -1, -1
 		for i, name := range order {
-			if name == "ubuntu" {
+			if name == "ubuntu:22.04" {
 				ubuntuIdx = i
 			}
-			if name == "python" {
+			if name == "python:3.13" {
 				pythonIdx = i
 			}
 		}
-		if ubuntuIdx > pythonIdx {
+		if ubuntuIdx == -1 || ubuntuIdx > pythonIdx {
 			t.Errorf("ubuntu (idx=%d) must come before python (idx=%d)", ubuntuIdx, pythonIdx)
 		}
 	})
 
+	t.Run("keeps the referenced tag", func(t *testing.T) {
+		dir := t.TempDir()
+
+		for _, tag := range []string{"22.04", "24.04"} {
+			os.MkdirAll(filepath.Join(dir, "ubuntu", tag), 0755)
+			os.WriteFile(filepath.Join(dir, "ubuntu", tag, "Dockerfile"), []byte("FROM ubuntu:"+tag), 0644)
+		}
+		os.MkdirAll(filepath.Join(dir, "python", "3.13-slim"), 0755)
+		os.WriteFile(filepath.Join(dir, "python", "3.13-slim", "Dockerfile"), []byte("FROM __hive__/ubuntu:24.04"), 0644)
+
+		graph, err := ScanRenderedProject(dir, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if diff := cmp.Diff([]string{"ubuntu:24.04"}, graph.Dependencies("python:3.13-slim")); diff != "" {
+			t.Errorf("dependencies mismatch (-expected +got):\n%s", diff)
+		}
+		if deps := graph.Dependents("ubuntu:22.04"); len(deps) != 0 {
+			t.Errorf("expected no dependents of ubuntu:22.04, got %v", deps)
+		}
+
+		expectedOrigin := EdgeOrigin{Kind: EdgeReference, File: filepath.Join(dir, "python", "3.13-slim", "Dockerfile"), Line: 1}
+		origin, _ := graph.Origin("python:3.13-slim", "ubuntu:24.04")
+		if diff := cmp.Diff(expectedOrigin, origin); diff != "" {
+			t.Errorf("origin mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("expands ARGs with the build args of the target", func(t *testing.T) {
+		dir := t.TempDir()
+
+		os.MkdirAll(filepath.Join(dir, "ubuntu", "24.04"), 0755)
+		os.WriteFile(filepath.Join(dir, "ubuntu", "24.04", "Dockerfile"), []byte("FROM ubuntu:24.04"), 0644)
+		os.MkdirAll(filepath.Join(dir, "python", "3.13"), 0755)
+		os.WriteFile(filepath.Join(dir, "python", "3.13", "Dockerfile"), []byte("ARG UBUNTU_VERSION=22.04\nFROM __hive__/ubuntu:${UBUNTU_VERSION}"), 0644)
+
+		graph, err := ScanRenderedProject(dir, map[string]model.BuildArgs{
+			"python:3.13": {"UBUNTU_VERSION": "24.04"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if diff := cmp.Diff([]string{"ubuntu:24.04"}, graph.Dependencies("python:3.13")); diff != "" {
+			t.Errorf("dependencies mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
 	t.Run("handles project with no __hive__ references", func(t *testing.T) {
 		dir := t.TempDir()
 
 		os.MkdirAll(filepath.Join(dir, "nginx", "1.27"), 0755)
 		os.WriteFile(filepath.Join(dir, "nginx", "1.27", "Dockerfile"), []byte("FROM nginx:alpine"), 0644)
 
-		graph, err := ScanRenderedProject(dir)
+		graph, err := ScanRenderedProject(dir, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -3,12 +3,13 @@ package docker
 import (
 	"context"
 	"errors"
-	"os"
+	"io"
 
 	dockerClient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/daemon"
-	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/timo-reymann/ContainerHive/internal/oci_layout"
 )
 
 type Client struct {
@@ -30,44 +31,23 @@ func NewClient() (*Client, error) {
 	}, nil
 }
 
-func (c *Client) LoadImageFromTar(_ context.Context, tarPath string) (string, error) {
-	tmpDir, err := os.MkdirTemp("", "oci-layout-*")
+// LoadImageFromTar loads the image for the platform from an OCI tar into the Docker daemon
+// and returns the image name it was tagged with.
+func (c *Client) LoadImageFromTar(_ context.Context, tarPath, platform string) (string, error) {
+	ociLayout, err := oci_layout.Open(tarPath)
 	if err != nil {
 		return "", err
 	}
-	defer os.RemoveAll(tmpDir)
+	defer ociLayout.Close()
 
-	if err := extractTar(tarPath, tmpDir); err != nil {
-		return "", errors.Join(errors.New("failed to extract OCI tar"), err)
-	}
-
-	layoutPath, err := layout.FromPath(tmpDir)
-	if err != nil {
-		return "", errors.Join(errors.New("failed to read OCI layout"), err)
-	}
-
-	idx, err := layoutPath.ImageIndex()
-	if err != nil {
-		return "", err
-	}
-
-	idxManifest, err := idx.IndexManifest()
-	if err != nil {
-		return "", err
-	}
-
-	if len(idxManifest.Manifests) == 0 {
-		return "", errors.New("no manifests in OCI layout")
-	}
-
-	imageName, ok := idxManifest.Manifests[0].Annotations["io.containerd.image.name"]
-	if !ok || imageName == "" {
+	imageName := ociLayout.ImageName()
+	if imageName == "" {
 		return "", errors.New("no image name annotation in OCI index")
 	}
 
-	img, err := layoutPath.Image(idxManifest.Manifests[0].Digest)
+	img, err := ociLayout.Image(platform)
 	if err != nil {
-		return "", errors.Join(errors.New("failed to read image from layout"), err)
+		return "", err
 	}
 
 	tag, err := name.NewTag(imageName)
@@ -81,3 +61,17 @@ func (c *Client) LoadImageFromTar(_ context.Context, tarPath string) (string, er
 
 	return imageName, nil
 }
+
+// LoadImage loads a docker image tar, e.g. streamed by the buildkit docker exporter, into the Docker daemon.
+func (c *Client) LoadImage(ctx context.Context, r io.Reader) error {
+	resp, err := c.docker.ImageLoad(ctx, r)
+	if err != nil {
+		return errors.Join(errors.New("failed to load image into Docker"), err)
+	}
+	defer resp.Body.Close()
+
+	if err := jsonmessage.DisplayJSONMessagesStream(resp.Body, io.Discard, 0, false, nil); err != nil {
+		return errors.Join(errors.New("failed to load image into Docker"), err)
+	}
+	return nil
+}
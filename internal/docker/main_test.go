@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -115,7 +116,7 @@ func TestLoadImageFromTar(t *testing.T) {
 	client := &Client{} // nil docker client — fine for tests that fail before daemon.Write
 
 	t.Run("returns error for nonexistent tar path", func(t *testing.T) {
-		_, err := client.LoadImageFromTar(context.Background(), "/nonexistent/image.tar")
+		_, err := client.LoadImageFromTar(context.Background(), "/nonexistent/image.tar", "linux/amd64")
 		if err == nil {
 			t.Fatal("expected error for nonexistent tar")
 		}
@@ -126,7 +127,7 @@ func TestLoadImageFromTar(t *testing.T) {
 		p := filepath.Join(t.TempDir(), "garbage.tar")
 		os.WriteFile(p, []byte("not a tar file at all"), 0644)
 
-		_, err := client.LoadImageFromTar(context.Background(), p)
+		_, err := client.LoadImageFromTar(context.Background(), p, "linux/amd64")
 		if err == nil {
 			t.Fatal("expected error for invalid tar data")
 		}
@@ -149,7 +150,7 @@ func TestLoadImageFromTar(t *testing.T) {
 		p := filepath.Join(t.TempDir(), "no-layout.tar")
 		os.WriteFile(p, buf.Bytes(), 0644)
 
-		_, err := client.LoadImageFromTar(context.Background(), p)
+		_, err := client.LoadImageFromTar(context.Background(), p, "linux/amd64")
 		if err == nil {
 			t.Fatal("expected error for tar without oci-layout")
 		}
@@ -159,7 +160,7 @@ func TestLoadImageFromTar(t *testing.T) {
 	t.Run("returns error when no manifests in index", func(t *testing.T) {
 		tarPath := buildOCITarNoManifests(t)
 
-		_, err := client.LoadImageFromTar(context.Background(), tarPath)
+		_, err := client.LoadImageFromTar(context.Background(), tarPath, "linux/amd64")
 		if err == nil {
 			t.Fatal("expected error for empty manifests")
 		}
@@ -172,7 +173,7 @@ func TestLoadImageFromTar(t *testing.T) {
 	t.Run("returns error when image name annotation is missing", func(t *testing.T) {
 		tarPath := buildOCITar(t, "") // empty image name → no annotation
 
-		_, err := client.LoadImageFromTar(context.Background(), tarPath)
+		_, err := client.LoadImageFromTar(context.Background(), tarPath, "linux/amd64")
 		if err == nil {
 			t.Fatal("expected error for missing image name annotation")
 		}
@@ -183,7 +184,7 @@ func TestLoadImageFromTar(t *testing.T) {
 		// Use an invalid Docker tag reference
 		tarPath := buildOCITar(t, "INVALID:!!!")
 
-		_, err := client.LoadImageFromTar(context.Background(), tarPath)
+		_, err := client.LoadImageFromTar(context.Background(), tarPath, "linux/amd64")
 		if err == nil {
 			t.Fatal("expected error for invalid image name")
 		}
@@ -201,7 +202,7 @@ func TestLoadImageFromTar(t *testing.T) {
 		}
 		defer dockerClient.Close()
 
-		_, err = dockerClient.LoadImageFromTar(context.Background(), tarPath)
+		_, err = dockerClient.LoadImageFromTar(context.Background(), tarPath, "linux/amd64")
 		if err == nil {
 			t.Fatal("expected error when Docker daemon is unreachable")
 		}
@@ -209,6 +210,21 @@ func TestLoadImageFromTar(t *testing.T) {
 	})
 }
 
+func TestLoadImage(t *testing.T) {
+	t.Run("returns error when daemon is not available", func(t *testing.T) {
+		t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:1")
+		dockerClient, err := NewClient()
+		if err != nil {
+			t.Fatal("failed to create docker client:", err)
+		}
+		defer dockerClient.Close()
+
+		if err := dockerClient.LoadImage(context.Background(), strings.NewReader("image")); err == nil {
+			t.Fatal("expected error when Docker daemon is unreachable")
+		}
+	})
+}
+
 func TestNewClient(t *testing.T) {
 	t.Run("creates client from environment", func(t *testing.T) {
 		client, err := NewClient()
@@ -0,0 +1,125 @@
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+// Inputs are everything of a rendered tag or variant that affects the built image.
+type Inputs struct {
+	// DistDir is the rendered directory of the tag or variant containing the Dockerfile and the rootfs
+	DistDir   string
+	BuildArgs model.BuildArgs
+	Versions  model.Versions
+	// SecretNames are the names of the resolved secrets, their values are never part of the fingerprint
+	SecretNames []string
+	Platforms   []string
+	// BaseDigests maps the graph keys of the dependencies to the digest of their built image
+	BaseDigests map[string]string
+	// SourceDateEpoch is the timestamp the image is built with
+	SourceDateEpoch int64
+	// Labels and Annotations are the configured ones, the generated ones only depend on the other inputs and git
+	Labels      model.Labels
+	Annotations model.Annotations
+}
+
+// Compute returns a stable fingerprint of the inputs in the form sha256:<hex>.
+// Map entries, secret names and rootfs files are hashed in sorted order, so the fingerprint only changes with the inputs.
+func Compute(inputs *Inputs) (string, error) {
+	h := sha256.New()
+
+	if err := hashFile(h, "Dockerfile", filepath.Join(inputs.DistDir, "Dockerfile")); err != nil {
+		return "", errors.Join(errors.New("failed to hash Dockerfile"), err)
+	}
+
+	if err := hashDir(h, filepath.Join(inputs.DistDir, "rootfs")); err != nil {
+		return "", errors.Join(errors.New("failed to hash rootfs"), err)
+	}
+
+	hashMap(h, "build-arg", inputs.BuildArgs)
+	hashMap(h, "version", inputs.Versions)
+	hashMap(h, "base", inputs.BaseDigests)
+	hashMap(h, "label", inputs.Labels)
+	hashMap(h, "annotation", inputs.Annotations)
+	for _, name := range slices.Sorted(slices.Values(inputs.SecretNames)) {
+		fmt.Fprintf(h, "secret\x00%s\n", name)
+	}
+	for _, platform := range slices.Sorted(slices.Values(inputs.Platforms)) {
+		fmt.Fprintf(h, "platform\x00%s\n", platform)
+	}
+	fmt.Fprintf(h, "source-date-epoch\x00%d\n", inputs.SourceDateEpoch)
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashMap[M ~map[string]string](h hash.Hash, kind string, m M) {
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\n", kind, k, m[k])
+	}
+}
+
+func hashFile(h hash.Hash, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	content := sha256.New()
+	if _, err := io.Copy(content, f); err != nil {
+		return err
+	}
+	fmt.Fprintf(h, "file\x00%s\x00%x\n", name, content.Sum(nil))
+	return nil
+}
+
+// hashDir hashes path, type, permissions and content of every entry in the directory.
+// A missing directory is hashed like an empty one.
+func hashDir(h hash.Hash, root string) error {
+	if _, err := os.Stat(root); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	// WalkDir visits entries in lexical order, which keeps the hash stable
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "symlink\x00%s\x00%s\n", rel, target)
+		case d.IsDir():
+			fmt.Fprintf(h, "dir\x00%s\x00%o\n", rel, info.Mode().Perm())
+		default:
+			fmt.Fprintf(h, "mode\x00%s\x00%o\n", rel, info.Mode().Perm())
+			return hashFile(h, rel, path)
+		}
+		return nil
+	})
+}
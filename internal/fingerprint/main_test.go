@@ -0,0 +1,148 @@
+package fingerprint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func renderedDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Dockerfile"), "FROM __hive__/ubuntu:22.04\nCOPY rootfs/ /\n")
+	writeFile(t, filepath.Join(dir, "rootfs", "etc", "motd"), "hello")
+	writeFile(t, filepath.Join(dir, "rootfs", "usr", "bin", "tool"), "#!/bin/sh")
+	return dir
+}
+
+func baseInputs(dir string) *Inputs {
+	return &Inputs{
+		DistDir:         dir,
+		BuildArgs:       model.BuildArgs{"FOO": "bar", "PYTHON_VERSION": "3.13.7"},
+		Versions:        model.Versions{"python": "3.13.7"},
+		SecretNames:     []string{"npm_token", "pip_index"},
+		Platforms:       []string{"linux/amd64", "linux/arm64"},
+		BaseDigests:     map[string]string{"ubuntu:22.04": "sha256:aaaa"},
+		SourceDateEpoch: 1700000000,
+		Labels:          model.Labels{"com.example.team": "platform"},
+		Annotations:     model.Annotations{"com.example.team": "platform"},
+	}
+}
+
+func mustCompute(t *testing.T, inputs *Inputs) string {
+	t.Helper()
+	fp, err := Compute(inputs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return fp
+}
+
+func TestCompute(t *testing.T) {
+	dir := renderedDir(t)
+	expected := mustCompute(t, baseInputs(dir))
+
+	t.Run("is stable", func(t *testing.T) {
+		inputs := baseInputs(dir)
+		inputs.SecretNames = []string{"pip_index", "npm_token"}
+		inputs.Platforms = []string{"linux/arm64", "linux/amd64"}
+		if got := mustCompute(t, inputs); got != expected {
+			t.Errorf("expected %s for reordered inputs, got %s", expected, got)
+		}
+		if got := mustCompute(t, baseInputs(renderedDir(t))); got != expected {
+			t.Errorf("expected %s for identical directory, got %s", expected, got)
+		}
+	})
+
+	testCases := []struct {
+		name   string
+		change func(t *testing.T, inputs *Inputs)
+	}{
+		{
+			name: "Dockerfile",
+			change: func(t *testing.T, inputs *Inputs) {
+				writeFile(t, filepath.Join(inputs.DistDir, "Dockerfile"), "FROM __hive__/ubuntu:24.04\n")
+			},
+		},
+		{
+			name: "rootfs content",
+			change: func(t *testing.T, inputs *Inputs) {
+				writeFile(t, filepath.Join(inputs.DistDir, "rootfs", "etc", "motd"), "changed")
+			},
+		},
+		{
+			name: "rootfs file added",
+			change: func(t *testing.T, inputs *Inputs) {
+				writeFile(t, filepath.Join(inputs.DistDir, "rootfs", "etc", "issue"), "")
+			},
+		},
+		{
+			name: "rootfs permissions",
+			change: func(t *testing.T, inputs *Inputs) {
+				if err := os.Chmod(filepath.Join(inputs.DistDir, "rootfs", "usr", "bin", "tool"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name:   "build arg",
+			change: func(_ *testing.T, inputs *Inputs) { inputs.BuildArgs["FOO"] = "baz" },
+		},
+		{
+			name:   "version",
+			change: func(_ *testing.T, inputs *Inputs) { inputs.Versions["python"] = "3.13.8" },
+		},
+		{
+			name:   "secret name",
+			change: func(_ *testing.T, inputs *Inputs) { inputs.SecretNames = []string{"npm_token"} },
+		},
+		{
+			name:   "platform",
+			change: func(_ *testing.T, inputs *Inputs) { inputs.Platforms = []string{"linux/amd64"} },
+		},
+		{
+			name:   "base digest",
+			change: func(_ *testing.T, inputs *Inputs) { inputs.BaseDigests["ubuntu:22.04"] = "sha256:bbbb" },
+		},
+		{
+			name:   "source date epoch",
+			change: func(_ *testing.T, inputs *Inputs) { inputs.SourceDateEpoch = 1700000001 },
+		},
+		{
+			name:   "label",
+			change: func(_ *testing.T, inputs *Inputs) { inputs.Labels["com.example.team"] = "data" },
+		},
+		{
+			name:   "annotation",
+			change: func(_ *testing.T, inputs *Inputs) { inputs.Annotations = nil },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("changes with "+tc.name, func(t *testing.T) {
+			inputs := baseInputs(renderedDir(t))
+			tc.change(t, inputs)
+			if got := mustCompute(t, inputs); got == expected {
+				t.Errorf("expected fingerprint to change, got %s", got)
+			}
+		})
+	}
+
+	t.Run("returns error without Dockerfile", func(t *testing.T) {
+		if _, err := Compute(&Inputs{DistDir: t.TempDir()}); err == nil {
+			t.Fatal("expected error for missing Dockerfile")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// Entry is the result of the last successful build of a tag or variant.
+type Entry struct {
+	Fingerprint string `json:"fingerprint"`
+	// Digest is the digest of the built image or image index
+	Digest string `json:"digest"`
+}
+
+// State records the last successful build of every tag or variant keyed by its graph node.
+// It is safe for concurrent use.
+type State struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+type stateFile struct {
+	Targets map[string]Entry `json:"targets"`
+}
+
+// NewState creates an empty state.
+func NewState() *State {
+	return &State{entries: make(map[string]Entry)}
+}
+
+// LoadState reads the state file, a missing file results in an empty state.
+func LoadState(path string) (*State, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read build state"), err)
+	}
+
+	var file stateFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, errors.Join(errors.New("failed to parse build state "+path), err)
+	}
+
+	state := NewState()
+	for key, entry := range file.Targets {
+		state.entries[key] = entry
+	}
+	return state, nil
+}
+
+// Get returns the entry of the target.
+func (s *State) Get(key string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	return entry, ok
+}
+
+// Set records a successful build of the target.
+func (s *State) Set(key string, entry Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = entry
+}
+
+// Delete removes the entry of the target, e.g. before it is rebuilt.
+func (s *State) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// Save writes the state file.
+func (s *State) Save(path string) error {
+	s.mu.Lock()
+	content, err := json.MarshalIndent(stateFile{Targets: s.entries}, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return errors.Join(errors.New("failed to write build state"), err)
+	}
+	return nil
+}
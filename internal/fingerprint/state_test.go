@@ -0,0 +1,57 @@
+package fingerprint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dist.state.json")
+
+	t.Run("missing file is empty state", func(t *testing.T) {
+		state, err := LoadState(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := state.Get("python:3.13"); ok {
+			t.Error("expected no entry")
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		state := NewState()
+		state.Set("python:3.13", Entry{Fingerprint: "sha256:1111", Digest: "sha256:aaaa"})
+		state.Set("ubuntu:22.04", Entry{Fingerprint: "sha256:2222", Digest: "sha256:bbbb"})
+		state.Delete("ubuntu:22.04")
+		if err := state.Save(path); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		loaded, err := LoadState(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		entry, ok := loaded.Get("python:3.13")
+		if !ok {
+			t.Fatal("expected entry for python:3.13")
+		}
+		if diff := cmp.Diff(Entry{Fingerprint: "sha256:1111", Digest: "sha256:aaaa"}, entry); diff != "" {
+			t.Errorf("entry mismatch (-expected +got):\n%s", diff)
+		}
+		if _, ok := loaded.Get("ubuntu:22.04"); ok {
+			t.Error("expected deleted entry to be gone")
+		}
+	})
+
+	t.Run("returns error for invalid file", func(t *testing.T) {
+		if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadState(path); err == nil {
+			t.Fatal("expected error for invalid state file")
+		}
+	})
+}
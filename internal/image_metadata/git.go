@@ -0,0 +1,77 @@
+package image_metadata
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Source is the repository images are built from.
+type Source struct {
+	// Revision is the commit checked out
+	Revision string
+	// URL is the web URL of the origin remote
+	URL string
+}
+
+// ResolveSource reads the checked out commit and the origin remote of the repository containing path.
+// Projects outside a repository result in an empty source, a missing remote in an empty URL.
+func ResolveSource(path string) (Source, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return Source{}, nil
+	}
+	if err != nil {
+		return Source{}, errors.Join(fmt.Errorf("failed to open git repository at %s", path), err)
+	}
+
+	source := Source{}
+	head, err := repo.Head()
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+	case err != nil:
+		return Source{}, errors.Join(errors.New("failed to resolve HEAD"), err)
+	default:
+		source.Revision = head.Hash().String()
+	}
+
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	switch {
+	case errors.Is(err, git.ErrRemoteNotFound):
+	case err != nil:
+		return Source{}, errors.Join(errors.New("failed to read origin remote"), err)
+	case len(remote.Config().URLs) > 0:
+		source.URL = webURL(remote.Config().URLs[0])
+	}
+	return source, nil
+}
+
+// webURL converts a remote URL into a web URL without credentials, e.g. git@github.com:org/repo.git
+// becomes https://github.com/org/repo. URLs it does not understand are returned as they are.
+func webURL(remote string) string {
+	// scp-like syntax, e.g. git@github.com:org/repo.git
+	if !strings.Contains(remote, "://") {
+		host, path, ok := strings.Cut(remote, ":")
+		if !ok || strings.Contains(host, "/") {
+			return remote
+		}
+		host = host[strings.LastIndex(host, "@")+1:]
+		remote = "ssh://" + host + "/" + strings.TrimPrefix(path, "/")
+	}
+
+	u, err := url.Parse(remote)
+	if err != nil || u.Host == "" {
+		return remote
+	}
+
+	web := url.URL{Scheme: "https", Host: u.Hostname(), Path: "/" + strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		web.Scheme = u.Scheme
+		web.Host = u.Host
+	}
+	return web.String()
+}
@@ -0,0 +1,59 @@
+package image_metadata
+
+import (
+	"maps"
+	"time"
+)
+
+// Keys of the pre-defined OCI annotations, see https://github.com/opencontainers/image-spec/blob/main/annotations.md
+const (
+	KeyCreated    = "org.opencontainers.image.created"
+	KeySource     = "org.opencontainers.image.source"
+	KeyVersion    = "org.opencontainers.image.version"
+	KeyRevision   = "org.opencontainers.image.revision"
+	KeyTitle      = "org.opencontainers.image.title"
+	KeyBaseName   = "org.opencontainers.image.base.name"
+	KeyBaseDigest = "org.opencontainers.image.base.digest"
+)
+
+// Info is everything the generated labels and annotations of a tag or variant are derived from.
+type Info struct {
+	// Title is the name of the image
+	Title string
+	// Version is the tag including the variant suffix
+	Version string
+	// Created is the SOURCE_DATE_EPOCH of the image
+	Created int64
+	Source  Source
+	// BaseName is the reference of the project image the final stage is based on, empty if there is none
+	BaseName   string
+	BaseDigest string
+}
+
+// Generate returns the org.opencontainers.image.* values for the info, unknown values are left out.
+func Generate(info Info) map[string]string {
+	values := map[string]string{
+		KeyTitle:   info.Title,
+		KeyVersion: info.Version,
+		KeyCreated: time.Unix(info.Created, 0).UTC().Format(time.RFC3339),
+	}
+	optional := map[string]string{
+		KeyRevision:   info.Source.Revision,
+		KeySource:     info.Source.URL,
+		KeyBaseName:   info.BaseName,
+		KeyBaseDigest: info.BaseDigest,
+	}
+	for k, v := range optional {
+		if v != "" {
+			values[k] = v
+		}
+	}
+	return values
+}
+
+// Merge returns the generated values overridden by the configured ones.
+func Merge(generated, configured map[string]string) map[string]string {
+	merged := maps.Clone(generated)
+	maps.Copy(merged, configured)
+	return merged
+}
@@ -0,0 +1,66 @@
+package image_metadata
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		info     Info
+		expected map[string]string
+	}{
+		{
+			name: "all values",
+			info: Info{
+				Title:      "python",
+				Version:    "3.13.7-node",
+				Created:    1700000000,
+				Source:     Source{Revision: "0123abcd", URL: "https://github.com/acme/images"},
+				BaseName:   "registry.example.com/library/ubuntu:22.04",
+				BaseDigest: "sha256:aaaa",
+			},
+			expected: map[string]string{
+				KeyTitle:      "python",
+				KeyVersion:    "3.13.7-node",
+				KeyCreated:    "2023-11-14T22:13:20Z",
+				KeyRevision:   "0123abcd",
+				KeySource:     "https://github.com/acme/images",
+				KeyBaseName:   "registry.example.com/library/ubuntu:22.04",
+				KeyBaseDigest: "sha256:aaaa",
+			},
+		},
+		{
+			name: "without git and base",
+			info: Info{Title: "ubuntu", Version: "22.04", Created: 0},
+			expected: map[string]string{
+				KeyTitle:   "ubuntu",
+				KeyVersion: "22.04",
+				KeyCreated: "1970-01-01T00:00:00Z",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Generate(tc.info)); diff != "" {
+				t.Errorf("Generate() mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	generated := map[string]string{KeyTitle: "python", KeyVersion: "3.13.7"}
+	configured := map[string]string{KeyTitle: "Python", "com.example.team": "platform"}
+
+	expected := map[string]string{KeyTitle: "Python", KeyVersion: "3.13.7", "com.example.team": "platform"}
+	if diff := cmp.Diff(expected, Merge(generated, configured)); diff != "" {
+		t.Errorf("Merge() mismatch (-expected +got):\n%s", diff)
+	}
+	if generated[KeyTitle] != "python" {
+		t.Error("expected generated values not to be modified")
+	}
+}
@@ -0,0 +1,165 @@
+package oci_layout
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/timo-reymann/ContainerHive/internal/utils"
+)
+
+// imageNameAnnotation is set by the buildkit oci exporter on the root descriptor.
+const imageNameAnnotation = "io.containerd.image.name"
+
+// Layout is an extracted OCI image layout tar as written by the buildkit oci exporter.
+// The root descriptor is either a single image manifest or an image index for multi-platform builds.
+type Layout struct {
+	dir   string
+	index v1.ImageIndex
+	root  v1.Descriptor
+}
+
+// Open extracts the OCI tar to a temporary directory, Close must be called to remove it.
+func Open(tarPath string) (*Layout, error) {
+	dir, err := os.MkdirTemp("", "oci-layout-*")
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := open(tarPath, dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return l, nil
+}
+
+func open(tarPath, dir string) (*Layout, error) {
+	if err := utils.ExtractTar(tarPath, dir); err != nil {
+		return nil, errors.Join(errors.New("failed to extract OCI tar"), err)
+	}
+
+	layoutPath, err := layout.FromPath(dir)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read OCI layout"), err)
+	}
+
+	idx, err := layoutPath.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	idxManifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(idxManifest.Manifests) == 0 {
+		return nil, errors.New("no manifests in OCI layout")
+	}
+
+	return &Layout{
+		dir:   dir,
+		index: idx,
+		root:  idxManifest.Manifests[0],
+	}, nil
+}
+
+// Close removes the extracted layout.
+func (l *Layout) Close() error {
+	return os.RemoveAll(l.dir)
+}
+
+// ImageName returns the image name annotated by buildkit or an empty string if it is missing.
+func (l *Layout) ImageName() string {
+	return l.root.Annotations[imageNameAnnotation]
+}
+
+// IsIndex reports whether the layout contains a multi-platform image index.
+func (l *Layout) IsIndex() bool {
+	return l.root.MediaType.IsIndex()
+}
+
+// Image returns the image for the platform in the form os/arch[/variant].
+// Single-platform layouts return their only image regardless of the platform.
+func (l *Layout) Image(platform string) (v1.Image, error) {
+	if !l.IsIndex() {
+		img, err := l.index.Image(l.root.Digest)
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to read image from layout"), err)
+		}
+		return img, nil
+	}
+
+	spec, err := v1.ParsePlatform(platform)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("invalid platform %s", platform), err)
+	}
+
+	idx, err := l.index.ImageIndex(l.root.Digest)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read image index from layout"), err)
+	}
+
+	idxManifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, desc := range idxManifest.Manifests {
+		if desc.Platform == nil || !desc.MediaType.IsImage() || !desc.Platform.Satisfies(*spec) {
+			continue
+		}
+		img, err := idx.Image(desc.Digest)
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to read image from layout"), err)
+		}
+		return img, nil
+	}
+
+	return nil, fmt.Errorf("no image for platform %s in OCI layout", platform)
+}
+
+// Push writes the root of the layout to the reference, multi-platform indexes are pushed as a whole.
+func (l *Layout) Push(ref name.Reference, options ...remote.Option) error {
+	if l.IsIndex() {
+		idx, err := l.index.ImageIndex(l.root.Digest)
+		if err != nil {
+			return errors.Join(errors.New("failed to read image index from layout"), err)
+		}
+		return remote.WriteIndex(ref, idx, options...)
+	}
+
+	img, err := l.index.Image(l.root.Digest)
+	if err != nil {
+		return errors.Join(errors.New("failed to read image from layout"), err)
+	}
+	return remote.Write(ref, img, options...)
+}
+
+// ReadDirDigest returns the root digest of an unpacked OCI layout directory.
+func ReadDirDigest(dir string) (v1.Hash, error) {
+	f, err := os.Open(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return v1.Hash{}, errors.Join(errors.New("failed to open index.json of OCI layout"), err)
+	}
+	defer f.Close()
+	return rootDigest(f)
+}
+
+func rootDigest(index io.Reader) (v1.Hash, error) {
+	idxManifest, err := v1.ParseIndexManifest(index)
+	if err != nil {
+		return v1.Hash{}, errors.Join(errors.New("failed to parse index.json"), err)
+	}
+	if len(idxManifest.Manifests) == 0 {
+		return v1.Hash{}, errors.New("no manifests in OCI layout")
+	}
+	return idxManifest.Manifests[0].Digest, nil
+}
@@ -0,0 +1,217 @@
+package oci_layout
+
+import (
+	"archive/tar"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func randomImage(t *testing.T) v1.Image {
+	t.Helper()
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// writeLayoutTar writes an OCI layout containing the appendable as root descriptor to a tar,
+// annotated with the image name like the buildkit oci exporter does.
+func writeLayoutTar(t *testing.T, appendable mutate.Appendable) string {
+	t.Helper()
+	dir := t.TempDir()
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := layout.WithAnnotations(map[string]string{imageNameAnnotation: "app:latest"})
+	switch a := appendable.(type) {
+	case v1.ImageIndex:
+		err = p.AppendIndex(a, annotations)
+	case v1.Image:
+		err = p.AppendImage(a, annotations)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tarPath := filepath.Join(t.TempDir(), "image.tar")
+	f, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	defer tw.Close()
+	if err := tw.AddFS(os.DirFS(dir)); err != nil {
+		t.Fatal(err)
+	}
+	return tarPath
+}
+
+func multiPlatformIndex(t *testing.T, amd64, arm64 v1.Image) v1.ImageIndex {
+	t.Helper()
+	return mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: amd64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+		mutate.IndexAddendum{Add: arm64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+	)
+}
+
+func mustDigest(t *testing.T, d interface{ Digest() (v1.Hash, error) }) v1.Hash {
+	t.Helper()
+	h, err := d.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestLayout_Image(t *testing.T) {
+	amd64, arm64 := randomImage(t), randomImage(t)
+
+	t.Run("selects platform from index", func(t *testing.T) {
+		l, err := Open(writeLayoutTar(t, multiPlatformIndex(t, amd64, arm64)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer l.Close()
+
+		if !l.IsIndex() {
+			t.Fatal("expected layout to contain an index")
+		}
+		if l.ImageName() != "app:latest" {
+			t.Errorf("unexpected image name %q", l.ImageName())
+		}
+
+		img, err := l.Image("linux/arm64")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if mustDigest(t, img) != mustDigest(t, arm64) {
+			t.Error("expected arm64 image")
+		}
+
+		if _, err := l.Image("linux/s390x"); err == nil || !strings.Contains(err.Error(), "linux/s390x") {
+			t.Errorf("expected missing platform error, got %v", err)
+		}
+	})
+
+	t.Run("single image ignores platform", func(t *testing.T) {
+		l, err := Open(writeLayoutTar(t, amd64))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer l.Close()
+
+		if l.IsIndex() {
+			t.Fatal("expected layout to contain a single image")
+		}
+		img, err := l.Image("linux/arm64")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if mustDigest(t, img) != mustDigest(t, amd64) {
+			t.Error("expected the only image of the layout")
+		}
+	})
+
+	t.Run("returns error for nonexistent tar", func(t *testing.T) {
+		if _, err := Open("/nonexistent/image.tar"); err == nil {
+			t.Fatal("expected error for nonexistent tar")
+		}
+	})
+}
+
+func TestLayout_Push(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	t.Run("pushes whole index", func(t *testing.T) {
+		idx := multiPlatformIndex(t, randomImage(t), randomImage(t))
+		l, err := Open(writeLayoutTar(t, idx))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer l.Close()
+
+		ref, err := name.NewTag(host+"/app:multi", name.Insecure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Push(ref); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		pushed, err := remote.Index(ref)
+		if err != nil {
+			t.Fatalf("failed to read pushed index: %v", err)
+		}
+		if mustDigest(t, pushed) != mustDigest(t, idx) {
+			t.Error("pushed index digest does not match")
+		}
+	})
+
+	t.Run("pushes single image", func(t *testing.T) {
+		img := randomImage(t)
+		l, err := Open(writeLayoutTar(t, img))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer l.Close()
+
+		ref, err := name.NewTag(host+"/app:single", name.Insecure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Push(ref); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		pushed, err := remote.Image(ref)
+		if err != nil {
+			t.Fatalf("failed to read pushed image: %v", err)
+		}
+		if mustDigest(t, pushed) != mustDigest(t, img) {
+			t.Error("pushed image digest does not match")
+		}
+	})
+}
+
+func TestReadDirDigest(t *testing.T) {
+	img := randomImage(t)
+	dir := t.TempDir()
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AppendImage(img); err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := ReadDirDigest(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if digest != mustDigest(t, img) {
+		t.Errorf("expected image digest, got %s", digest)
+	}
+
+	t.Run("returns error for directory without index", func(t *testing.T) {
+		if _, err := ReadDirDigest(t.TempDir()); err == nil {
+			t.Fatal("expected error for directory without index.json")
+		}
+	})
+}
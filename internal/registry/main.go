@@ -14,17 +14,42 @@ type Registry interface {
 	IsLocal() bool
 }
 
+const defaultRemoteRegistry = "docker.io"
+
 // NewRegistry creates a Registry based on the environment.
-// In CI (CI env var set), it returns a remote registry passthrough.
+// In CI (CI env var set), it returns a remote registry passthrough to the
+// CONTAINER_HIVE_REGISTRY env var or the configured staging address.
 // Otherwise, it returns an embedded zot registry for local builds.
-func NewRegistry() Registry {
+func NewRegistry(stagingAddress string) Registry {
 	if ci := os.Getenv("CI"); ci != "" {
 		remoteAddr := os.Getenv("CONTAINER_HIVE_REGISTRY")
 		if remoteAddr == "" {
-			// TODO Use actual config value from tbd global configuration file
-			remoteAddr = "docker.io"
+			remoteAddr = stagingAddress
+		}
+		if remoteAddr == "" {
+			remoteAddr = defaultRemoteRegistry
 		}
 		return NewRemoteRegistry(remoteAddr)
 	}
 	return NewZotRegistry()
 }
+
+// namespacedRegistry stages images below a sub path of the wrapped registry.
+type namespacedRegistry struct {
+	Registry
+	namespace string
+}
+
+// WithNamespace stages all images below namespace in the registry, e.g. to keep parallel CI jobs
+// that build the same bases from overwriting each other's images.
+func WithNamespace(reg Registry, namespace string) Registry {
+	return &namespacedRegistry{Registry: reg, namespace: namespace}
+}
+
+func (r *namespacedRegistry) Address() string {
+	return r.Registry.Address() + "/" + r.namespace
+}
+
+func (r *namespacedRegistry) Push(ctx context.Context, imageName, tag, ociTarPath string) error {
+	return r.Registry.Push(ctx, r.namespace+"/"+imageName, tag, ociTarPath)
+}
@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/timo-reymann/ContainerHive/internal/oci_layout"
+)
+
+// Publish pushes an OCI tar to the repository once per tag, authenticating with the docker config.
+// Multi-platform indexes are pushed as a whole.
+func Publish(ctx context.Context, repository string, tags []string, ociTarPath string) error {
+	ociLayout, err := oci_layout.Open(ociTarPath)
+	if err != nil {
+		return errors.Join(errors.New("failed to read OCI tar for publish"), err)
+	}
+	defer ociLayout.Close()
+
+	for _, tag := range tags {
+		ref, err := name.NewTag(repository + ":" + tag)
+		if err != nil {
+			return errors.Join(errors.New("invalid image reference"), err)
+		}
+
+		if err := ociLayout.Push(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain)); err != nil {
+			return errors.Join(fmt.Errorf("failed to publish %s", ref), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package registry
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestPublish(t *testing.T) {
+	server := httptest.NewServer(ggcrregistry.New())
+	defer server.Close()
+	repository := strings.TrimPrefix(server.URL, "http://") + "/library/python"
+
+	t.Run("pushes all tags", func(t *testing.T) {
+		if err := Publish(t.Context(), repository, []string{"3.13.7", "3.13", "3"}, buildOCITar(t)); err != nil {
+			t.Fatalf("publish failed: %v", err)
+		}
+
+		repo, err := name.NewRepository(repository)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags, err := remote.List(repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if diff := cmp.Diff([]string{"3", "3.13", "3.13.7"}, tags); diff != "" {
+			t.Errorf("tags mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("returns error for nonexistent tar", func(t *testing.T) {
+		if err := Publish(t.Context(), repository, []string{"latest"}, "/nonexistent/image.tar"); err == nil {
+			t.Fatal("expected error for nonexistent tar")
+		}
+	})
+}
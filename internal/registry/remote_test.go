@@ -1,7 +1,14 @@
 package registry
 
 import (
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 )
 
 func TestRemoteRegistry(t *testing.T) {
@@ -34,7 +41,7 @@ func TestNewRegistry_CI(t *testing.T) {
 	t.Run("returns remote registry when CI is set", func(t *testing.T) {
 		t.Setenv("CI", "true")
 		t.Setenv("CONTAINER_HIVE_REGISTRY", "")
-		reg := NewRegistry()
+		reg := NewRegistry("")
 		if reg.IsLocal() {
 			t.Error("expected remote registry in CI mode")
 		}
@@ -46,7 +53,25 @@ func TestNewRegistry_CI(t *testing.T) {
 	t.Run("uses CONTAINER_HIVE_REGISTRY when set", func(t *testing.T) {
 		t.Setenv("CI", "true")
 		t.Setenv("CONTAINER_HIVE_REGISTRY", "ghcr.io/myorg")
-		reg := NewRegistry()
+		reg := NewRegistry("")
+		if reg.Address() != "ghcr.io/myorg" {
+			t.Errorf("expected ghcr.io/myorg, got %s", reg.Address())
+		}
+	})
+
+	t.Run("uses staging address when CONTAINER_HIVE_REGISTRY is not set", func(t *testing.T) {
+		t.Setenv("CI", "true")
+		t.Setenv("CONTAINER_HIVE_REGISTRY", "")
+		reg := NewRegistry("registry.example.com/staging")
+		if reg.Address() != "registry.example.com/staging" {
+			t.Errorf("expected registry.example.com/staging, got %s", reg.Address())
+		}
+	})
+
+	t.Run("CONTAINER_HIVE_REGISTRY takes precedence over staging address", func(t *testing.T) {
+		t.Setenv("CI", "true")
+		t.Setenv("CONTAINER_HIVE_REGISTRY", "ghcr.io/myorg")
+		reg := NewRegistry("registry.example.com/staging")
 		if reg.Address() != "ghcr.io/myorg" {
 			t.Errorf("expected ghcr.io/myorg, got %s", reg.Address())
 		}
@@ -54,9 +79,35 @@ func TestNewRegistry_CI(t *testing.T) {
 
 	t.Run("returns zot registry when CI is not set", func(t *testing.T) {
 		t.Setenv("CI", "")
-		reg := NewRegistry()
+		reg := NewRegistry("")
 		if !reg.IsLocal() {
 			t.Error("expected local (zot) registry when CI not set")
 		}
 	})
 }
+
+func TestWithNamespace(t *testing.T) {
+	server := httptest.NewServer(ggcrregistry.New())
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://") + "/staging"
+
+	reg := WithNamespace(NewRemoteRegistry(address), "shard-1-of-2")
+	if expected := address + "/shard-1-of-2"; reg.Address() != expected {
+		t.Errorf("expected %s, got %s", expected, reg.Address())
+	}
+	if err := reg.Push(t.Context(), "ubuntu", "22.04", buildOCITar(t)); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+
+	repo, err := name.NewRepository(reg.Address() + "/ubuntu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := remote.List(repo)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if diff := cmp.Diff([]string{"22.04"}, tags); diff != "" {
+		t.Errorf("tags mismatch (-expected +got):\n%s", diff)
+	}
+}
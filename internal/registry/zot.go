@@ -9,9 +9,7 @@ import (
 	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
-	"github.com/google/go-containerregistry/pkg/v1/layout"
-	"github.com/google/go-containerregistry/pkg/v1/remote"
-	"github.com/timo-reymann/ContainerHive/internal/utils"
+	"github.com/timo-reymann/ContainerHive/internal/oci_layout"
 	"zotregistry.dev/zot/v2/pkg/api"
 	"zotregistry.dev/zot/v2/pkg/api/config"
 )
@@ -115,46 +113,18 @@ func (z *ZotRegistry) IsLocal() bool {
 }
 
 func (z *ZotRegistry) Push(_ context.Context, imageName, tag, ociTarPath string) error {
-	tmpDir, err := os.MkdirTemp("", "oci-push-*")
+	ociLayout, err := oci_layout.Open(ociTarPath)
 	if err != nil {
-		return err
-	}
-	defer os.RemoveAll(tmpDir)
-
-	if err := utils.ExtractTar(ociTarPath, tmpDir); err != nil {
-		return errors.Join(errors.New("failed to extract OCI tar for push"), err)
-	}
-
-	layoutPath, err := layout.FromPath(tmpDir)
-	if err != nil {
-		return errors.Join(errors.New("failed to read OCI layout"), err)
-	}
-
-	idx, err := layoutPath.ImageIndex()
-	if err != nil {
-		return err
-	}
-
-	idxManifest, err := idx.IndexManifest()
-	if err != nil {
-		return err
-	}
-
-	if len(idxManifest.Manifests) == 0 {
-		return errors.New("no manifests in OCI layout")
-	}
-
-	img, err := layoutPath.Image(idxManifest.Manifests[0].Digest)
-	if err != nil {
-		return errors.Join(errors.New("failed to read image from layout"), err)
+		return errors.Join(errors.New("failed to read OCI tar for push"), err)
 	}
+	defer ociLayout.Close()
 
 	ref, err := name.NewTag(fmt.Sprintf("%s/%s:%s", z.Address(), imageName, tag), name.Insecure)
 	if err != nil {
 		return errors.Join(errors.New("invalid image reference"), err)
 	}
 
-	if err := remote.Write(ref, img); err != nil {
+	if err := ociLayout.Push(ref); err != nil {
 		return errors.Join(errors.New("failed to push image to zot"), err)
 	}
 
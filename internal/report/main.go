@@ -0,0 +1,215 @@
+package report
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the name of the report file inside the report directory.
+const FileName = "build-report.json"
+
+// Status is the outcome of a build, SBOM or test step.
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	// StatusSkipped marks steps that did not run, e.g. tests without definitions
+	StatusSkipped Status = "skipped"
+	// StatusUnchanged marks builds that reused the image of the previous run because their inputs did not change
+	StatusUnchanged Status = "unchanged"
+)
+
+// BuildResult is the outcome of building a tag or variant.
+type BuildResult struct {
+	Status    Status   `json:"status"`
+	Duration  float64  `json:"duration_seconds"`
+	Platforms []string `json:"platforms,omitempty"`
+	TarPath   string   `json:"tar_path,omitempty"`
+	Digest    string   `json:"digest,omitempty"`
+	// LogPath is the log output of all build steps, it is only set for builds that ran
+	LogPath   string `json:"log_path,omitempty"`
+	TracePath string `json:"trace_path,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SBOMResult is the outcome of generating the SBOM for a platform of a tag or variant.
+type SBOMResult struct {
+	Platform string  `json:"platform"`
+	Status   Status  `json:"status"`
+	Duration float64 `json:"duration_seconds"`
+	Path     string  `json:"path,omitempty"`
+	Size     int     `json:"size_bytes,omitempty"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// TestResult is the outcome of the container-structure-tests for a platform of a tag or variant.
+type TestResult struct {
+	Platform  string  `json:"platform"`
+	Status    Status  `json:"status"`
+	Duration  float64 `json:"duration_seconds"`
+	Passed    int     `json:"passed"`
+	Failed    int     `json:"failed"`
+	JUnitPath string  `json:"junit_path,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Target identifies a tag or variant of an image.
+type Target struct {
+	Image string `json:"image"`
+	// Tag is the full tag including the tag suffix of the variant
+	Tag     string `json:"tag"`
+	Variant string `json:"variant,omitempty"`
+}
+
+// TargetReport collects the results of all steps for a tag or variant.
+type TargetReport struct {
+	Target
+	Build *BuildResult `json:"build,omitempty"`
+	SBOMs []SBOMResult `json:"sboms,omitempty"`
+	Tests []TestResult `json:"tests,omitempty"`
+}
+
+// Report is the machine-readable result of a run, it is safe for concurrent use.
+// build starts a new report, sbom and test add their results to it.
+type Report struct {
+	mu      sync.Mutex
+	started time.Time
+	targets map[string]*TargetReport
+}
+
+type reportFile struct {
+	StartedAt time.Time       `json:"started_at"`
+	Targets   []*TargetReport `json:"targets"`
+}
+
+// New creates an empty report for a run starting now.
+func New() *Report {
+	return &Report{
+		started: time.Now().UTC(),
+		targets: make(map[string]*TargetReport),
+	}
+}
+
+// Load reads an existing report to add results to, a missing file results in a new report.
+func Load(path string) (*Report, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read report"), err)
+	}
+
+	var file reportFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, errors.Join(errors.New("failed to parse report "+path), err)
+	}
+
+	r := &Report{
+		started: file.StartedAt,
+		targets: make(map[string]*TargetReport, len(file.Targets)),
+	}
+	for _, t := range file.Targets {
+		r.targets[t.key()] = t
+	}
+	return r, nil
+}
+
+func (t Target) key() string {
+	return t.Image + ":" + t.Tag
+}
+
+// target returns the report of the target, creating it if necessary. The lock must be held.
+func (r *Report) target(t Target) *TargetReport {
+	tr, ok := r.targets[t.key()]
+	if !ok {
+		tr = &TargetReport{Target: t}
+		r.targets[t.key()] = tr
+	}
+	return tr
+}
+
+// SetBuild records the build result of the target.
+func (r *Report) SetBuild(t Target, result BuildResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.target(t).Build = &result
+}
+
+// HasBuild reports whether a build result has been recorded for the target.
+func (r *Report) HasBuild(t Target) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tr, ok := r.targets[t.key()]
+	return ok && tr.Build != nil
+}
+
+// BuildDurations returns the duration of every successful build keyed by image:tag, e.g. to weight shards.
+// Builds that reused the previous image are left out, their duration says nothing about the build time.
+func (r *Report) BuildDurations() map[string]float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	durations := make(map[string]float64)
+	for key, tr := range r.targets {
+		if tr.Build != nil && tr.Build.Status == StatusSucceeded {
+			durations[key] = tr.Build.Duration
+		}
+	}
+	return durations
+}
+
+// SetSBOM records the SBOM result of the target, replacing a previous result for the same platform.
+func (r *Report) SetSBOM(t Target, result SBOMResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tr := r.target(t)
+	tr.SBOMs = slices.DeleteFunc(tr.SBOMs, func(s SBOMResult) bool { return s.Platform == result.Platform })
+	tr.SBOMs = append(tr.SBOMs, result)
+}
+
+// SetTest records the test result of the target, replacing a previous result for the same platform.
+func (r *Report) SetTest(t Target, result TestResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tr := r.target(t)
+	tr.Tests = slices.DeleteFunc(tr.Tests, func(s TestResult) bool { return s.Platform == result.Platform })
+	tr.Tests = append(tr.Tests, result)
+}
+
+// Save writes the report with the targets sorted by image and tag.
+func (r *Report) Save(path string) error {
+	r.mu.Lock()
+	file := reportFile{StartedAt: r.started}
+	for _, t := range r.targets {
+		file.Targets = append(file.Targets, t)
+	}
+	slices.SortFunc(file.Targets, func(a, b *TargetReport) int {
+		return cmp.Or(strings.Compare(a.Image, b.Image), strings.Compare(a.Tag, b.Tag))
+	})
+	content, err := json.MarshalIndent(file, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return errors.Join(errors.New("failed to write report"), err)
+	}
+	return nil
+}
+
+// ErrorString returns the message of err or an empty string for nil errors.
+func ErrorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
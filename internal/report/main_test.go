@@ -0,0 +1,106 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	python := Target{Image: "python", Tag: "3.13-slim", Variant: "slim"}
+	ubuntu := Target{Image: "ubuntu", Tag: "22.04"}
+
+	r := New()
+	r.SetBuild(ubuntu, BuildResult{Status: StatusUnchanged, TarPath: "dist/ubuntu/22.04/image.tar", Digest: "sha256:aaaa"})
+	r.SetBuild(python, BuildResult{Status: StatusFailed, Error: "build failed"})
+	if err := r.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("adds results to existing report", func(t *testing.T) {
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		loaded.SetSBOM(ubuntu, SBOMResult{Platform: "linux/amd64", Status: StatusFailed, Error: "boom"})
+		loaded.SetSBOM(ubuntu, SBOMResult{Platform: "linux/amd64", Status: StatusSucceeded, Path: "image.tar.linux-amd64.sbom.spdx.json", Size: 42})
+		loaded.SetTest(ubuntu, TestResult{Platform: "linux/amd64", Status: StatusSucceeded, Passed: 3, JUnitPath: "reports/ubuntu.xml"})
+		if err := loaded.Save(path); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var file reportFile
+		if err := json.Unmarshal(content, &file); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []*TargetReport{
+			{
+				Target: python,
+				Build:  &BuildResult{Status: StatusFailed, Error: "build failed"},
+			},
+			{
+				Target: ubuntu,
+				Build:  &BuildResult{Status: StatusUnchanged, TarPath: "dist/ubuntu/22.04/image.tar", Digest: "sha256:aaaa"},
+				SBOMs:  []SBOMResult{{Platform: "linux/amd64", Status: StatusSucceeded, Path: "image.tar.linux-amd64.sbom.spdx.json", Size: 42}},
+				Tests:  []TestResult{{Platform: "linux/amd64", Status: StatusSucceeded, Passed: 3, JUnitPath: "reports/ubuntu.xml"}},
+			},
+		}
+		if diff := cmp.Diff(expected, file.Targets); diff != "" {
+			t.Errorf("report mismatch (-expected +got):\n%s", diff)
+		}
+		if !file.StartedAt.Equal(r.started) {
+			t.Errorf("expected start time of the build to be kept, got %v", file.StartedAt)
+		}
+	})
+
+	t.Run("missing file is new report", func(t *testing.T) {
+		loaded, err := Load(filepath.Join(t.TempDir(), FileName))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(loaded.targets) != 0 {
+			t.Errorf("expected empty report, got %d targets", len(loaded.targets))
+		}
+	})
+
+	t.Run("returns error for invalid file", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), FileName)
+		if err := os.WriteFile(invalid, []byte("["), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(invalid); err == nil {
+			t.Fatal("expected error for invalid report")
+		}
+	})
+}
+
+func TestReport_BuildDurations(t *testing.T) {
+	r := New()
+	r.SetBuild(Target{Image: "ubuntu", Tag: "22.04"}, BuildResult{Status: StatusSucceeded, Duration: 120})
+	r.SetBuild(Target{Image: "python", Tag: "3.13"}, BuildResult{Status: StatusUnchanged, Duration: 0.2})
+	r.SetBuild(Target{Image: "python", Tag: "3.13-slim", Variant: "slim"}, BuildResult{Status: StatusFailed, Duration: 3})
+	r.SetSBOM(Target{Image: "node", Tag: "24"}, SBOMResult{Platform: "linux/amd64", Status: StatusSucceeded, Duration: 10})
+
+	if diff := cmp.Diff(map[string]float64{"ubuntu:22.04": 120}, r.BuildDurations()); diff != "" {
+		t.Errorf("durations mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	if got := ErrorString(nil); got != "" {
+		t.Errorf("expected empty string, got %q", got)
+	}
+	if got := ErrorString(errors.New("boom")); got != "boom" {
+		t.Errorf("expected boom, got %q", got)
+	}
+}
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+)
+
+// FailurePolicy controls how the scheduler proceeds after a node failed.
+type FailurePolicy string
+
+const (
+	// FailFast cancels running nodes and starts no new ones after the first failure.
+	FailFast FailurePolicy = "fail-fast"
+	// Continue runs every node once its dependencies finished, regardless of whether they failed.
+	// Dependents of a failed node are run as well and will usually fail against its missing or outdated image,
+	// use SkipDependents to only keep going with nodes that do not depend on the failure.
+	Continue FailurePolicy = "continue"
+	// SkipDependents runs all nodes except the transitive dependents of failed nodes.
+	SkipDependents FailurePolicy = "skip-dependents"
+)
+
+// FailurePolicies are all supported failure policies.
+var FailurePolicies = []FailurePolicy{FailFast, Continue, SkipDependents}
+
+// ParseFailurePolicy validates the name of a failure policy.
+func ParseFailurePolicy(name string) (FailurePolicy, error) {
+	policy := FailurePolicy(name)
+	if !slices.Contains(FailurePolicies, policy) {
+		names := make([]string, len(FailurePolicies))
+		for i, p := range FailurePolicies {
+			names[i] = string(p)
+		}
+		return "", fmt.Errorf("unsupported failure policy %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return policy, nil
+}
+
+// RunFunc processes a single node of the graph.
+type RunFunc func(ctx context.Context, node string) error
+
+// SkipFunc is called for nodes that are not run, because their dependency failed or was skipped itself.
+type SkipFunc func(node, failedDependency string)
+
+// Scheduler runs the nodes of a dependency graph concurrently.
+// A node is started as soon as all of its dependencies finished, with at most Jobs nodes running at the same time.
+// What happens after a node failed depends on the Policy.
+type Scheduler struct {
+	Graph  *dependency.Graph
+	Jobs   int
+	Policy FailurePolicy
+	// OnSkip is notified about nodes skipped with the SkipDependents policy
+	OnSkip SkipFunc
+}
+
+// New creates a fail-fast scheduler for the graph, a job limit below one is treated as one.
+func New(graph *dependency.Graph, jobs int) *Scheduler {
+	return &Scheduler{
+		Graph:  graph,
+		Jobs:   max(jobs, 1),
+		Policy: FailFast,
+	}
+}
+
+// WithPolicy sets the failure policy and the callback for skipped nodes.
+func (s *Scheduler) WithPolicy(policy FailurePolicy, onSkip SkipFunc) *Scheduler {
+	s.Policy = policy
+	s.OnSkip = onSkip
+	return s
+}
+
+type outcome struct {
+	node string
+	err  error
+}
+
+// Run executes run for every node of the graph in dependency order.
+// With FailFast the first error cancels the context passed to running nodes, prevents new nodes from
+// being started and is returned once all running nodes returned.
+// The other policies keep going and return the errors of all failed nodes joined.
+func (s *Scheduler) Run(ctx context.Context, run RunFunc) error {
+	order, err := s.Graph.TopologicalSort()
+	if err != nil {
+		return errors.Join(errors.New("failed to schedule graph"), err)
+	}
+
+	pending := make(map[string]int, len(order))
+	dependents := make(map[string][]string, len(order))
+	for _, node := range order {
+		deps := slices.Compact(slices.Sorted(slices.Values(s.Graph.Dependencies(node))))
+		pending[node] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], node)
+		}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, s.Jobs)
+	done := make(chan outcome, len(order))
+	start := func(node string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-runCtx.Done():
+				done <- outcome{node, runCtx.Err()}
+				return
+			}
+			if err := runCtx.Err(); err != nil {
+				done <- outcome{node, err}
+				return
+			}
+			done <- outcome{node, run(runCtx, node)}
+		}()
+	}
+
+	// blocked maps nodes that must be skipped to the failed dependency causing it
+	blocked := make(map[string]string)
+	completed := 0
+	var release func(node string)
+	skip := func(node string) {
+		completed++
+		if s.OnSkip != nil {
+			s.OnSkip(node, blocked[node])
+		}
+		for _, dependent := range dependents[node] {
+			if _, ok := blocked[dependent]; !ok {
+				blocked[dependent] = blocked[node]
+			}
+		}
+		release(node)
+	}
+	release = func(node string) {
+		for _, dependent := range dependents[node] {
+			pending[dependent]--
+			if pending[dependent] > 0 {
+				continue
+			}
+			if _, ok := blocked[dependent]; ok {
+				skip(dependent)
+			} else {
+				start(dependent)
+			}
+		}
+	}
+
+	stop := func(err error) error {
+		cancel()
+		wg.Wait()
+		return err
+	}
+
+	for _, node := range order {
+		if pending[node] == 0 {
+			start(node)
+		}
+	}
+
+	var errs []error
+	for completed < len(order) {
+		if err := ctx.Err(); err != nil {
+			return stop(errors.Join(append(errs, err)...))
+		}
+
+		var o outcome
+		select {
+		case <-ctx.Done():
+			return stop(errors.Join(append(errs, ctx.Err())...))
+		case o = <-done:
+		}
+		completed++
+
+		if o.err != nil {
+			if s.Policy == FailFast {
+				return stop(o.err)
+			}
+			errs = append(errs, o.err)
+			if s.Policy == SkipDependents {
+				for _, dependent := range dependents[o.node] {
+					if _, ok := blocked[dependent]; !ok {
+						blocked[dependent] = o.node
+					}
+				}
+			}
+		}
+		release(o.node)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,246 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+)
+
+func newGraph(nodes []string, edges map[string][]string) *dependency.Graph {
+	g := dependency.NewGraph()
+	for _, node := range nodes {
+		g.AddImage(node)
+	}
+	for from, deps := range edges {
+		for _, to := range deps {
+			g.AddDependency(from, to)
+		}
+	}
+	return g
+}
+
+func TestScheduler_Run(t *testing.T) {
+	t.Run("runs dependencies before dependents", func(t *testing.T) {
+		g := newGraph(
+			[]string{"base", "left", "right", "top"},
+			map[string][]string{
+				"left":  {"base"},
+				"right": {"base"},
+				"top":   {"left", "right", "left"},
+			},
+		)
+
+		var mu sync.Mutex
+		var order []string
+		err := New(g, 4).Run(t.Context(), func(_ context.Context, node string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, node)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(order) != 4 {
+			t.Fatalf("expected 4 nodes to run, got %v", order)
+		}
+		if order[0] != "base" || order[3] != "top" {
+			t.Errorf("unexpected order %v", order)
+		}
+	})
+
+	t.Run("runs independent nodes concurrently", func(t *testing.T) {
+		g := newGraph([]string{"a", "b"}, nil)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		done := make(chan error, 1)
+		go func() {
+			done <- New(g, 2).Run(t.Context(), func(_ context.Context, _ string) error {
+				wg.Done()
+				// Both nodes have to be running at the same time to pass the barrier
+				wg.Wait()
+				return nil
+			})
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("independent nodes did not run concurrently")
+		}
+	})
+
+	t.Run("respects job limit", func(t *testing.T) {
+		g := newGraph([]string{"a", "b", "c", "d", "e", "f"}, nil)
+
+		var running, maxRunning atomic.Int32
+		err := New(g, 2).Run(t.Context(), func(_ context.Context, _ string) error {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				seen := maxRunning.Load()
+				if current <= seen || maxRunning.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if maxRunning.Load() > 2 {
+			t.Errorf("expected at most 2 concurrent jobs, got %d", maxRunning.Load())
+		}
+	})
+
+	t.Run("does not start dependents of failed nodes", func(t *testing.T) {
+		g := newGraph(
+			[]string{"base", "app"},
+			map[string][]string{"app": {"base"}},
+		)
+
+		expectedErr := errors.New("build failed")
+		var ran []string
+		err := New(g, 1).Run(t.Context(), func(_ context.Context, node string) error {
+			ran = append(ran, node)
+			if node == "base" {
+				return expectedErr
+			}
+			return nil
+		})
+		if !errors.Is(err, expectedErr) {
+			t.Fatalf("expected build error, got %v", err)
+		}
+		if !slices.Equal(ran, []string{"base"}) {
+			t.Errorf("expected only base to run, got %v", ran)
+		}
+	})
+
+	t.Run("returns error on cycle", func(t *testing.T) {
+		g := newGraph(
+			[]string{"a", "b"},
+			map[string][]string{"a": {"b"}, "b": {"a"}},
+		)
+
+		err := New(g, 1).Run(t.Context(), func(_ context.Context, _ string) error {
+			t.Error("no node should run")
+			return nil
+		})
+		if err == nil {
+			t.Fatal("expected cycle error")
+		}
+	})
+
+	t.Run("stops when context is cancelled", func(t *testing.T) {
+		g := newGraph(
+			[]string{"a", "b"},
+			map[string][]string{"b": {"a"}},
+		)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		err := New(g, 1).Run(ctx, func(_ context.Context, node string) error {
+			if node == "b" {
+				t.Error("b should not run after cancellation")
+			}
+			cancel()
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("continue runs dependents of failed nodes", func(t *testing.T) {
+		g := newGraph(
+			[]string{"base", "app", "other"},
+			map[string][]string{"app": {"base"}},
+		)
+
+		var mu sync.Mutex
+		var ran []string
+		err := New(g, 2).WithPolicy(Continue, nil).Run(t.Context(), func(_ context.Context, node string) error {
+			mu.Lock()
+			ran = append(ran, node)
+			mu.Unlock()
+			if node == "base" || node == "other" {
+				return errors.New(node + " failed")
+			}
+			return nil
+		})
+		if err == nil || !strings.Contains(err.Error(), "base failed") || !strings.Contains(err.Error(), "other failed") {
+			t.Fatalf("expected all errors to be joined, got %v", err)
+		}
+		slices.Sort(ran)
+		if !slices.Equal(ran, []string{"app", "base", "other"}) {
+			t.Errorf("expected all nodes to run, got %v", ran)
+		}
+	})
+
+	t.Run("skip-dependents skips transitive dependents", func(t *testing.T) {
+		g := newGraph(
+			[]string{"base", "app", "plugin", "other", "tool"},
+			map[string][]string{
+				"app":    {"base"},
+				"plugin": {"app", "tool"},
+			},
+		)
+
+		var mu sync.Mutex
+		var ran []string
+		skipped := make(map[string]string)
+		err := New(g, 2).WithPolicy(SkipDependents, func(node, failedDependency string) {
+			skipped[node] = failedDependency
+		}).Run(t.Context(), func(_ context.Context, node string) error {
+			mu.Lock()
+			ran = append(ran, node)
+			mu.Unlock()
+			if node == "base" {
+				return errors.New("base failed")
+			}
+			return nil
+		})
+		if err == nil || !strings.Contains(err.Error(), "base failed") {
+			t.Fatalf("expected base error, got %v", err)
+		}
+		slices.Sort(ran)
+		if !slices.Equal(ran, []string{"base", "other", "tool"}) {
+			t.Errorf("expected independent nodes to run, got %v", ran)
+		}
+		expected := map[string]string{"app": "base", "plugin": "base"}
+		if !maps.Equal(skipped, expected) {
+			t.Errorf("expected skipped %v, got %v", expected, skipped)
+		}
+	})
+
+	t.Run("job limit below one runs sequentially", func(t *testing.T) {
+		s := New(dependency.NewGraph(), 0)
+		if s.Jobs != 1 {
+			t.Errorf("expected 1 job, got %d", s.Jobs)
+		}
+	})
+}
+
+func TestParseFailurePolicy(t *testing.T) {
+	for _, policy := range FailurePolicies {
+		if got, err := ParseFailurePolicy(string(policy)); err != nil || got != policy {
+			t.Errorf("expected %s, got %s (%v)", policy, got, err)
+		}
+	}
+	if _, err := ParseFailurePolicy("ignore"); err == nil {
+		t.Error("expected error for unsupported policy")
+	}
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/timo-reymann/ContainerHive/internal/dependency"
+)
+
+// Shard selects the part of the workload a CI worker builds, Index is 1-based.
+type Shard struct {
+	Index int
+	Count int
+}
+
+// ParseShard parses a shard in the form i/n, e.g. 2/4 for the second of four shards.
+func ParseShard(value string) (Shard, error) {
+	index, count, ok := strings.Cut(value, "/")
+	if !ok {
+		return Shard{}, fmt.Errorf("invalid shard %q, expected i/n", value)
+	}
+	shard := Shard{}
+	var err error
+	if shard.Index, err = strconv.Atoi(index); err != nil {
+		return Shard{}, fmt.Errorf("invalid shard %q, expected i/n", value)
+	}
+	if shard.Count, err = strconv.Atoi(count); err != nil {
+		return Shard{}, fmt.Errorf("invalid shard %q, expected i/n", value)
+	}
+	if shard.Count < 1 || shard.Index < 1 || shard.Index > shard.Count {
+		return Shard{}, fmt.Errorf("invalid shard %q, expected 1 <= i <= n", value)
+	}
+	return shard, nil
+}
+
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Partition splits the nodes of the graph into count balanced shards.
+// The top-level nodes, which no other node depends on, are distributed across the shards, and every shard
+// additionally contains all their transitive dependencies, so it can be built on its own. Shared bases are built in
+// every shard that requires them instead of forcing all their dependents into one shard.
+// Nodes without weight count as the average known weight. Top-level nodes are assigned heaviest first, each to the
+// shard with the lowest total weight after adding the dependencies it does not contain yet.
+// The result only depends on the graph and the weights, so every worker computes the same partition.
+func Partition(graph *dependency.Graph, weights map[string]float64, count int) [][]string {
+	defaultWeight := 1.0
+	if len(weights) > 0 {
+		total := 0.0
+		for _, w := range weights {
+			total += w
+		}
+		defaultWeight = total / float64(len(weights))
+	}
+	weightOf := func(node string) float64 {
+		if w, ok := weights[node]; ok {
+			return w
+		}
+		return defaultWeight
+	}
+
+	type unit struct {
+		nodes  []string
+		weight float64
+	}
+	var units []unit
+	for _, node := range graph.TopLevel() {
+		u := unit{nodes: append(graph.TransitiveDependencies(node), node)}
+		for _, n := range u.nodes {
+			u.weight += weightOf(n)
+		}
+		units = append(units, u)
+	}
+	slices.SortStableFunc(units, func(a, b unit) int {
+		return cmp.Compare(b.weight, a.weight)
+	})
+
+	shards := make([]map[string]bool, count)
+	loads := make([]float64, count)
+	for i := range shards {
+		shards[i] = make(map[string]bool)
+	}
+	for _, u := range units {
+		best, bestLoad := 0, 0.0
+		for i, shard := range shards {
+			load := loads[i]
+			for _, n := range u.nodes {
+				if !shard[n] {
+					load += weightOf(n)
+				}
+			}
+			if i == 0 || load < bestLoad {
+				best, bestLoad = i, load
+			}
+		}
+		for _, n := range u.nodes {
+			shards[best][n] = true
+		}
+		loads[best] = bestLoad
+	}
+
+	result := make([][]string, count)
+	for i, shard := range shards {
+		if len(shard) > 0 {
+			result[i] = slices.Sorted(maps.Keys(shard))
+		}
+	}
+	return result
+}
+
+// Nodes returns the nodes of the graph belonging to the shard.
+func (s Shard) Nodes(graph *dependency.Graph, weights map[string]float64) []string {
+	return Partition(graph, weights, s.Count)[s.Index-1]
+}
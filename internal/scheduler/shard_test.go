@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseShard(t *testing.T) {
+	shard, err := ParseShard("2/4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if shard != (Shard{Index: 2, Count: 4}) {
+		t.Errorf("unexpected shard %v", shard)
+	}
+	if shard.String() != "2/4" {
+		t.Errorf("unexpected string %s", shard.String())
+	}
+
+	for _, invalid := range []string{"", "2", "0/4", "5/4", "1/0", "a/4", "1/b", "-1/4"} {
+		if _, err := ParseShard(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+	g := newGraph(
+		[]string{"ubuntu:22.04", "python:3.13", "app:1.0", "golang:1.25", "golang:1.25-alpine", "node:24", "rust:1.90"},
+		map[string][]string{
+			"python:3.13":        {"ubuntu:22.04"},
+			"app:1.0":            {"python:3.13"},
+			"golang:1.25-alpine": {"golang:1.25"},
+		},
+	)
+
+	testCases := []struct {
+		name     string
+		weights  map[string]float64
+		count    int
+		expected [][]string
+	}{
+		{
+			name:  "single shard",
+			count: 1,
+			expected: [][]string{
+				{"app:1.0", "golang:1.25", "golang:1.25-alpine", "node:24", "python:3.13", "rust:1.90", "ubuntu:22.04"},
+			},
+		},
+		{
+			name:  "unweighted",
+			count: 2,
+			expected: [][]string{
+				{"app:1.0", "python:3.13", "rust:1.90", "ubuntu:22.04"},
+				{"golang:1.25", "golang:1.25-alpine", "node:24"},
+			},
+		},
+		{
+			name:    "weighted by historic durations",
+			count:   2,
+			weights: map[string]float64{"rust:1.90": 600, "ubuntu:22.04": 60, "python:3.13": 120, "app:1.0": 30, "golang:1.25": 90, "node:24": 45},
+			expected: [][]string{
+				{"rust:1.90"},
+				{"app:1.0", "golang:1.25", "golang:1.25-alpine", "node:24", "python:3.13", "ubuntu:22.04"},
+			},
+		},
+		{
+			name:  "more shards than components",
+			count: 5,
+			expected: [][]string{
+				{"app:1.0", "python:3.13", "ubuntu:22.04"},
+				{"golang:1.25", "golang:1.25-alpine"},
+				{"node:24"},
+				{"rust:1.90"},
+				nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Partition(g, tc.weights, tc.count)); diff != "" {
+				t.Errorf("Partition() mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("shards cover every node exactly once", func(t *testing.T) {
+		seen := make(map[string]int)
+		for i := 1; i <= 3; i++ {
+			for _, node := range (Shard{Index: i, Count: 3}).Nodes(g, nil) {
+				seen[node]++
+			}
+		}
+		for _, node := range g.Nodes() {
+			if seen[node] != 1 {
+				t.Errorf("expected %s in exactly one shard, got %d", node, seen[node])
+			}
+		}
+	})
+}
+
+func TestPartition_SharedBase(t *testing.T) {
+	nodes := []string{"ubuntu:22.04", "python:3.13"}
+	edges := map[string][]string{"python:3.13": {"ubuntu:22.04"}}
+	for _, app := range []string{"a", "b", "c", "d", "e", "f"} {
+		nodes = append(nodes, app+":1.0")
+		edges[app+":1.0"] = []string{"python:3.13"}
+	}
+	g := newGraph(nodes, edges)
+
+	shards := Partition(g, nil, 3)
+	expected := [][]string{
+		{"a:1.0", "d:1.0", "python:3.13", "ubuntu:22.04"},
+		{"b:1.0", "e:1.0", "python:3.13", "ubuntu:22.04"},
+		{"c:1.0", "f:1.0", "python:3.13", "ubuntu:22.04"},
+	}
+	if diff := cmp.Diff(expected, shards); diff != "" {
+		t.Errorf("Partition() mismatch (-expected +got):\n%s", diff)
+	}
+
+	t.Run("shards are balanced", func(t *testing.T) {
+		minSize, maxSize := len(nodes), 0
+		for _, shard := range shards {
+			minSize, maxSize = min(minSize, len(shard)), max(maxSize, len(shard))
+		}
+		if maxSize-minSize > 1 {
+			t.Errorf("expected balanced shards, got sizes between %d and %d", minSize, maxSize)
+		}
+	})
+
+	t.Run("shards contain all dependencies", func(t *testing.T) {
+		for i, shard := range shards {
+			for _, node := range shard {
+				for _, dep := range g.Dependencies(node) {
+					if !slices.Contains(shard, dep) {
+						t.Errorf("shard %d contains %s, but not its dependency %s", i+1, node, dep)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("top-level nodes are built once", func(t *testing.T) {
+		seen := make(map[string]int)
+		for _, shard := range shards {
+			for _, node := range shard {
+				seen[node]++
+			}
+		}
+		for _, node := range g.TopLevel() {
+			if seen[node] != 1 {
+				t.Errorf("expected %s in exactly one shard, got %d", node, seen[node])
+			}
+		}
+	})
+
+	t.Run("heavy shared base is built in every shard", func(t *testing.T) {
+		weights := map[string]float64{"ubuntu:22.04": 1000, "python:3.13": 1, "a:1.0": 1, "b:1.0": 1, "c:1.0": 1, "d:1.0": 1, "e:1.0": 1, "f:1.0": 1}
+		for i, shard := range Partition(g, weights, 2) {
+			if !slices.Contains(shard, "ubuntu:22.04") || len(shard) != 5 {
+				t.Errorf("expected shard %d to build the base and three apps, got %v", i+1, shard)
+			}
+		}
+	})
+}
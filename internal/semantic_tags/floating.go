@@ -0,0 +1,70 @@
+package semantic_tags
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// LatestTag is the floating tag assigned to the highest version of a tag group.
+const LatestTag = "latest"
+
+// FloatingTags computes the floating aliases for a group of tags published to the same repository.
+// A tag receives a lower variant of its version (e.g. 3.13 and 3 for 3.13.7) only if it is the highest
+// version sharing that prefix. With latest set, the highest version of the group also receives latest.
+// Tags that are no valid versions are ignored and aliases colliding with an existing tag are skipped.
+// The result maps tags to their aliases, tags without aliases are omitted.
+func FloatingTags(tags []string, latest bool) map[string][]string {
+	existing := make(map[string]bool, len(tags))
+	versions := make(map[string]*SemanticTagVersion, len(tags))
+	for _, tag := range tags {
+		existing[tag] = true
+		if version, err := NewSemanticVersion(tag); err == nil {
+			versions[tag] = version
+		}
+	}
+
+	// Visit tags in a fixed order so ties between equal versions resolve deterministically
+	owners := make(map[string]string)
+	for _, tag := range slices.Sorted(maps.Keys(versions)) {
+		version := versions[tag]
+		candidates := version.GetLowerVariants()
+		if latest {
+			candidates = append(candidates, LatestTag)
+		}
+		for _, alias := range candidates {
+			if existing[alias] {
+				continue
+			}
+			owner, ok := owners[alias]
+			if !ok || version.Greater(versions[owner]) {
+				owners[alias] = tag
+			}
+		}
+	}
+
+	aliases := make(map[string][]string)
+	for alias, owner := range owners {
+		aliases[owner] = append(aliases[owner], alias)
+	}
+	for tag := range aliases {
+		slices.SortFunc(aliases[tag], compareAliases)
+	}
+	return aliases
+}
+
+// compareAliases orders more specific aliases first, e.g. 3.13 before 3 before latest.
+func compareAliases(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == LatestTag:
+		return 1
+	case b == LatestTag:
+		return -1
+	}
+	if n := strings.Count(b, ".") - strings.Count(a, "."); n != 0 {
+		return n
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,71 @@
+package semantic_tags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloatingTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tags     []string
+		latest   bool
+		expected map[string][]string
+	}{
+		{
+			name:     "Single version",
+			tags:     []string{"3.13.7"},
+			expected: map[string][]string{"3.13.7": {"3.13", "3"}},
+		},
+		{
+			name:   "Highest version owns shared prefixes",
+			tags:   []string{"3.13.7", "3.13.6", "3.12.11"},
+			latest: true,
+			expected: map[string][]string{
+				"3.13.7":  {"3.13", "3", "latest"},
+				"3.12.11": {"3.12"},
+			},
+		},
+		{
+			name: "Versions are compared numerically",
+			tags: []string{"8.0.9", "8.0.10"},
+			expected: map[string][]string{
+				"8.0.10": {"8.0", "8"},
+			},
+		},
+		{
+			name: "Aliases colliding with existing tags are skipped",
+			tags: []string{"3.13.7", "3.13", "latest"},
+			expected: map[string][]string{
+				"3.13.7": {"3"},
+			},
+		},
+		{
+			name:   "Prefixes form separate groups",
+			tags:   []string{"v1.2.3", "1.3.0"},
+			latest: true,
+			expected: map[string][]string{
+				"v1.2.3": {"v1.2", "v1"},
+				"1.3.0":  {"1.3", "1", "latest"},
+			},
+		},
+		{
+			name:     "Invalid versions are ignored",
+			tags:     []string{"bookworm", "3.13.7-rc1"},
+			latest:   true,
+			expected: map[string][]string{},
+		},
+		{
+			name:     "Major only without latest has no aliases",
+			tags:     []string{"22"},
+			expected: map[string][]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, FloatingTags(tc.tags, tc.latest))
+		})
+	}
+}
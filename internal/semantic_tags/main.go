@@ -1,4 +1,4 @@
-package main
+package semantic_tags
 
 import (
 	"fmt"
@@ -0,0 +1,181 @@
+package source_date
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+// Fallback is used for images without git history, e.g. projects outside a repository or uncommitted images.
+const Fallback int64 = 1770336000
+
+// Resolve returns the SOURCE_DATE_EPOCH of every image by its identifier. A configured epoch takes precedence,
+// otherwise it is the commit time of the last commit touching the image directory in the local repository.
+func Resolve(project *model.ContainerHiveProject) (map[string]int64, error) {
+	epochs := make(map[string]int64, len(project.ImagesByIdentifier))
+	pending := make(map[string]string)
+	for identifier, image := range project.ImagesByIdentifier {
+		if image.SourceDateEpoch != nil {
+			epochs[identifier] = *image.SourceDateEpoch
+			continue
+		}
+		epochs[identifier] = Fallback
+		pending[identifier] = image.RootDir
+	}
+	if len(pending) == 0 {
+		return epochs, nil
+	}
+
+	commitTimes, err := lastCommitTimes(project.RootDir, pending)
+	if err != nil {
+		return nil, err
+	}
+	for identifier, epoch := range commitTimes {
+		epochs[identifier] = epoch
+	}
+	return epochs, nil
+}
+
+// lastCommitTimes walks the history of HEAD once, newest commit first, and returns the commit time of the first
+// commit changing a file below each of the directories. Directories not found in the history are left out.
+func lastCommitTimes(root string, dirs map[string]string) (map[string]int64, error) {
+	repo, err := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{DetectDotGit: true})
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to open git repository at %s", root), err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to open git worktree"), err)
+	}
+
+	pending, err := relativeDirs(worktree.Filesystem.Root(), dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to resolve HEAD"), err)
+	}
+
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read HEAD commit"), err)
+	}
+
+	// Shallow clones end at commits whose parents are missing, like git those are treated as root commits
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read shallow commits"), err)
+	}
+	var missing []plumbing.Hash
+	for _, hash := range shallow {
+		if commit, err := repo.CommitObject(hash); err == nil {
+			missing = append(missing, commit.ParentHashes...)
+		}
+	}
+
+	commits := object.NewCommitIterCTime(headCommit, nil, missing)
+	defer commits.Close()
+
+	times := make(map[string]int64, len(pending))
+	err = commits.ForEach(func(commit *object.Commit) error {
+		changed, err := changedFiles(commit, slices.Contains(shallow, commit.Hash))
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to diff commit %s", commit.Hash), err)
+		}
+		for identifier, dir := range pending {
+			if touches(changed, dir) {
+				times[identifier] = commit.Committer.When.Unix()
+				delete(pending, identifier)
+			}
+		}
+		if len(pending) == 0 {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read git history"), err)
+	}
+	return times, nil
+}
+
+// relativeDirs converts the directories to slash separated paths relative to the worktree root like in git trees.
+func relativeDirs(worktreeRoot string, dirs map[string]string) (map[string]string, error) {
+	worktreeRoot, err := filepath.EvalSymlinks(worktreeRoot)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to resolve git worktree"), err)
+	}
+	relative := make(map[string]string, len(dirs))
+	for identifier, dir := range dirs {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to resolve image directory %s", dir), err)
+		}
+		rel, err := filepath.Rel(worktreeRoot, resolved)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		relative[identifier] = filepath.ToSlash(rel)
+	}
+	return relative, nil
+}
+
+// changedFiles returns the paths changed by the commit compared to its first parent, or all paths for a root commit.
+func changedFiles(commit *object.Commit, root bool) ([]string, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if !root && commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" {
+				files = append(files, name)
+			}
+		}
+	}
+	return files, nil
+}
+
+func touches(files []string, dir string) bool {
+	if dir == "." {
+		return len(files) > 0
+	}
+	for _, file := range files {
+		if strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
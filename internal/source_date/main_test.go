@@ -0,0 +1,195 @@
+package source_date
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-cmp/cmp"
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+)
+
+func commitFiles(t *testing.T, worktree *git.Worktree, root string, when time.Time, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: when}
+	if _, err := worktree.Commit("change", &git.CommitOptions{Author: signature, Committer: signature}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testProject(root string, configured *int64) *model.ContainerHiveProject {
+	image := func(identifier string) *model.Image {
+		return &model.Image{Identifier: identifier, RootDir: filepath.Join(root, "images", identifier)}
+	}
+	pinned := image("pinned")
+	pinned.SourceDateEpoch = configured
+	return &model.ContainerHiveProject{
+		RootDir: root,
+		ImagesByIdentifier: map[string]*model.Image{
+			"python":    image("python"),
+			"dotnet/8":  image("dotnet/8"),
+			"ubuntu":    image("ubuntu"),
+			"pinned":    pinned,
+			"untracked": image("untracked"),
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := time.Unix(1700000000, 0)
+	second := time.Unix(1700001000, 0)
+	third := time.Unix(1700002000, 0)
+	commitFiles(t, worktree, root, first, map[string]string{
+		"hive.yml":                   "",
+		"images/python/Dockerfile":   "FROM python",
+		"images/dotnet/8/Dockerfile": "FROM dotnet",
+		"images/ubuntu/Dockerfile":   "FROM ubuntu",
+		"images/pinned/Dockerfile":   "FROM scratch",
+	})
+	commitFiles(t, worktree, root, second, map[string]string{
+		"images/dotnet/8/rootfs/info": "v2",
+		"images/pinned/Dockerfile":    "FROM alpine",
+	})
+	commitFiles(t, worktree, root, third, map[string]string{
+		"images/python/image.yml": "tags: []",
+		"images/ubuntu-extra":     "not part of ubuntu",
+		"README.md":               "docs",
+	})
+	if err := os.MkdirAll(filepath.Join(root, "images", "untracked"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configured := int64(1600000000)
+	epochs, err := Resolve(testProject(root, &configured))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]int64{
+		"python":    third.Unix(),
+		"dotnet/8":  second.Unix(),
+		"ubuntu":    first.Unix(),
+		"pinned":    configured,
+		"untracked": Fallback,
+	}
+	if diff := cmp.Diff(expected, epochs); diff != "" {
+		t.Errorf("Resolve() mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestResolve_WithoutRepository(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"python", "dotnet/8", "ubuntu", "pinned", "untracked"} {
+		if err := os.MkdirAll(filepath.Join(root, "images", dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	epochs, err := Resolve(testProject(root, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for identifier, epoch := range epochs {
+		if epoch != Fallback {
+			t.Errorf("expected fallback for %s, got %d", identifier, epoch)
+		}
+	}
+}
+
+func TestResolve_WithoutCommits(t *testing.T) {
+	root := t.TempDir()
+	if _, err := git.PlainInit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "images", "python"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	project := &model.ContainerHiveProject{
+		RootDir: root,
+		ImagesByIdentifier: map[string]*model.Image{
+			"python": {Identifier: "python", RootDir: filepath.Join(root, "images", "python")},
+		},
+	}
+
+	epochs, err := Resolve(project)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]int64{"python": Fallback}, epochs); diff != "" {
+		t.Errorf("Resolve() mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestResolve_ShallowClone(t *testing.T) {
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := time.Unix(1700000000, 0)
+	second := time.Unix(1700001000, 0)
+	commitFiles(t, worktree, root, first, map[string]string{"images/python/Dockerfile": "FROM python"})
+	initial, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, worktree, root, second, map[string]string{"README.md": "docs"})
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cut the history like git clone --depth 1 does
+	if err := repo.Storer.SetShallow([]plumbing.Hash{head.Hash()}); err != nil {
+		t.Fatal(err)
+	}
+	hash := initial.Hash().String()
+	if err := os.Remove(filepath.Join(root, ".git", "objects", hash[:2], hash[2:])); err != nil {
+		t.Fatal(err)
+	}
+
+	project := &model.ContainerHiveProject{
+		RootDir: root,
+		ImagesByIdentifier: map[string]*model.Image{
+			"python": {Identifier: "python", RootDir: filepath.Join(root, "images", "python")},
+		},
+	}
+	epochs, err := Resolve(project)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]int64{"python": second.Unix()}, epochs); diff != "" {
+		t.Errorf("Resolve() mismatch (-expected +got):\n%s", diff)
+	}
+}
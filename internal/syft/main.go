@@ -2,11 +2,19 @@ package syft
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+
+	"github.com/anchore/stereoscope/pkg/image/oci"
 
 	"github.com/anchore/syft/syft"
 	"github.com/anchore/syft/syft/format"
 	"github.com/anchore/syft/syft/sbom"
+	"github.com/anchore/syft/syft/source"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/timo-reymann/ContainerHive/internal/oci_layout"
 
 	_ "modernc.org/sqlite" // required for rpmdb and other features
 )
@@ -27,8 +35,46 @@ func NewSBOMImageTool() (*SBOMImageTool, error) {
 	}, nil
 }
 
-func (s *SBOMImageTool) GenerateSBOM(ctx context.Context, tarPath string) (*sbom.SBOM, error) {
-	src, err := syft.GetSource(ctx, tarPath, nil)
+// GenerateSBOM generates the SBOM for the image of the given platform inside an OCI tar.
+// Multi-platform tars are reduced to a single-platform OCI layout first, as syft only reads the first manifest.
+func (s *SBOMImageTool) GenerateSBOM(ctx context.Context, tarPath, platform string) (*sbom.SBOM, error) {
+	ociLayout, err := oci_layout.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer ociLayout.Close()
+
+	if !ociLayout.IsIndex() {
+		return s.generate(ctx, tarPath, syft.DefaultGetSourceConfig())
+	}
+
+	img, err := ociLayout.Image(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "sbom-oci-layout-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	platformLayout, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to write platform OCI layout"), err)
+	}
+	if err := platformLayout.AppendImage(img); err != nil {
+		return nil, errors.Join(errors.New("failed to write platform OCI layout"), err)
+	}
+
+	cfg := syft.DefaultGetSourceConfig().
+		WithSources(string(oci.Directory)).
+		WithAlias(source.Alias{Name: ociLayout.ImageName()})
+	return s.generate(ctx, dir, cfg)
+}
+
+func (s *SBOMImageTool) generate(ctx context.Context, input string, cfg *syft.GetSourceConfig) (*sbom.SBOM, error) {
+	src, err := syft.GetSource(ctx, input, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -37,7 +37,7 @@ func TestSBOMImageTool_GenerateSBOM(t *testing.T) {
 
 	t.Run("generates SBOM from valid alpine tar image", func(t *testing.T) {
 		t.Log("Generating SBOM from testdata/alpine.tar")
-		sbom, err := tool.GenerateSBOM(ctx, "testdata/alpine.tar")
+		sbom, err := tool.GenerateSBOM(ctx, "testdata/alpine.tar", "linux/amd64")
 		if err != nil {
 			t.Fatalf("GenerateSBOM() error = %v", err)
 		}
@@ -58,7 +58,7 @@ func TestSBOMImageTool_GenerateSBOM(t *testing.T) {
 
 	t.Run("returns error for non-existent tar file", func(t *testing.T) {
 		t.Log("Testing error handling for non-existent file")
-		_, err := tool.GenerateSBOM(ctx, "testdata/nonexistent.tar")
+		_, err := tool.GenerateSBOM(ctx, "testdata/nonexistent.tar", "linux/amd64")
 		if err == nil {
 			t.Fatal("GenerateSBOM() expected error for non-existent tar, got nil")
 		}
@@ -67,7 +67,7 @@ func TestSBOMImageTool_GenerateSBOM(t *testing.T) {
 
 	t.Run("returns error for invalid tar path", func(t *testing.T) {
 		t.Log("Testing error handling for invalid path")
-		_, err := tool.GenerateSBOM(ctx, "/invalid/path/to/nowhere.tar")
+		_, err := tool.GenerateSBOM(ctx, "/invalid/path/to/nowhere.tar", "linux/amd64")
 		if err == nil {
 			t.Fatal("GenerateSBOM() expected error for invalid path, got nil")
 		}
@@ -79,7 +79,7 @@ func TestSBOMImageTool_GenerateSBOM(t *testing.T) {
 		cancelCtx, cancel := context.WithCancel(ctx)
 		cancel() // Cancel immediately
 
-		_, err := tool.GenerateSBOM(cancelCtx, "testdata/alpine.tar")
+		_, err := tool.GenerateSBOM(cancelCtx, "testdata/alpine.tar", "linux/amd64")
 		// May or may not error depending on timing, but should not panic
 		if err != nil {
 			t.Logf("✓ Handled cancelled context: %v", err)
@@ -98,7 +98,7 @@ func TestSBOMImageTool_SerializeSBOM(t *testing.T) {
 
 	ctx := context.Background()
 	t.Log("Generating SBOM from testdata/alpine.tar")
-	sbom, err := tool.GenerateSBOM(ctx, "testdata/alpine.tar")
+	sbom, err := tool.GenerateSBOM(ctx, "testdata/alpine.tar", "linux/amd64")
 	if err != nil {
 		t.Fatalf("GenerateSBOM() error = %v", err)
 	}
@@ -209,7 +209,7 @@ func TestSBOMImageTool_SerializeSBOM_InvalidFormat(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	sbom, err := tool.GenerateSBOM(ctx, "testdata/alpine.tar")
+	sbom, err := tool.GenerateSBOM(ctx, "testdata/alpine.tar", "linux/amd64")
 	if err != nil {
 		t.Fatalf("GenerateSBOM() error = %v", err)
 	}
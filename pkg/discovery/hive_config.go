@@ -2,8 +2,13 @@ package discovery
 
 import (
 	"errors"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+
+	"github.com/timo-reymann/ContainerHive/pkg/model"
+	"gopkg.in/yaml.v3"
 )
 
 var hiveConfigFileNames = []string{
@@ -28,3 +33,40 @@ func getContainerHiveConfigFile(root string) (string, error) {
 
 	return "", errors.New("no ContainerHive config file found")
 }
+
+func parseHiveConfigFile(configFilePath string) (*model.HiveProjectConfig, error) {
+	f, err := os.Open(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d := yaml.NewDecoder(f)
+	d.KnownFields(true)
+	var config model.HiveProjectConfig
+	if err := d.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// applyProjectDefaults merges the project-wide versions, platforms and source date epoch into the image.
+// Values defined on the image take precedence. Build args are kept separately, as tag build args
+// override project-wide ones, but not image ones.
+func applyProjectDefaults(image *model.Image, config *model.HiveProjectConfig) {
+	if len(config.Versions) > 0 {
+		versions := maps.Clone(config.Versions)
+		maps.Copy(versions, image.Versions)
+		image.Versions = versions
+	}
+
+	image.DefaultBuildArgs = config.BuildArgs
+
+	if len(image.Platforms) == 0 {
+		image.Platforms = config.Platforms
+	}
+
+	if image.SourceDateEpoch == nil {
+		image.SourceDateEpoch = config.SourceDateEpoch
+	}
+}
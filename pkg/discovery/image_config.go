@@ -112,6 +112,11 @@ func processImageConfig(projectRoot, configFilePath string) (*model.Image, error
 		Variants:            indexedVariants,
 		Tags:                processTags(parsedImageDef),
 		DependsOn:           parsedImageDef.DependsOn,
+		Platforms:           parsedImageDef.Platforms,
+		FloatingTags:        parsedImageDef.FloatingTags,
+		SourceDateEpoch:     parsedImageDef.SourceDateEpoch,
+		Labels:              parsedImageDef.Labels,
+		Annotations:         parsedImageDef.Annotations,
 	}, nil
 }
 
@@ -152,6 +157,10 @@ func processVariants(imageDef *model.ImageDefinitionConfig, imageRoot string) (m
 			Versions:            v.Versions,
 			BuildArgs:           v.BuildArgs,
 			RootFSDir:           variantFsRoot,
+			Secrets:             ensureSecretsInitialized(v.Secrets),
+			Platforms:           v.Platforms,
+			Labels:              v.Labels,
+			Annotations:         v.Annotations,
 		}
 
 		indexedVariants[v.Name] = variant
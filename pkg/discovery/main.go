@@ -103,18 +103,25 @@ func DiscoverProject(ctx context.Context, root string) (*model.ContainerHiveProj
 		return nil, errors.Join(errors.New("failed to determine absolute config path"), err)
 	}
 
+	config, err := parseHiveConfigFile(absoluteConfigPath)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to parse ContainerHive config file"), err)
+	}
+
 	images, err := discoverImages(ctx, filepath.Join(absoluteRoot, "images"))
 	if err != nil {
 		return nil, errors.Join(errors.New("failed to discover images"), err)
 	}
 	imagesByName := make(map[string][]*model.Image)
 	for _, image := range images {
+		applyProjectDefaults(image, config)
 		imagesByName[image.Name] = append(imagesByName[image.Name], image)
 	}
 
 	project := &model.ContainerHiveProject{
 		RootDir:            absoluteRoot,
 		ConfigFilePath:     absoluteConfigPath,
+		Config:             config,
 		ImagesByIdentifier: images,
 		ImagesByName:       imagesByName,
 	}
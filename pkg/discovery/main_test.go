@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -29,6 +30,7 @@ func TestDiscoverProject(t *testing.T) {
 			expected: &model.ContainerHiveProject{
 				RootDir:        mustAbs(t, "../testdata/simple-project"),
 				ConfigFilePath: mustAbs(t, "../testdata/simple-project/hive.yml"),
+				Config:         &model.HiveProjectConfig{},
 				ImagesByIdentifier: map[string]*model.Image{
 					"dotnet/8": {
 						BuildEntryPointPath: mustAbs(t, "../testdata/simple-project/images/dotnet/8/Dockerfile"),
@@ -47,6 +49,9 @@ func TestDiscoverProject(t *testing.T) {
 								TestConfigFilePath:  mustAbs(t, "../testdata/simple-project/images/dotnet/8/node/test.yml.gotpl"),
 								TagSuffix:           "-node",
 								Versions:            model.Versions{"nodejs": "24"},
+								Secrets: model.Secrets{
+									"npm_token": {SourceType: "plain", Value: "npm-token"},
+								},
 							},
 						},
 						BuildArgs: model.BuildArgs{"foo": "bar"},
@@ -125,6 +130,9 @@ func TestDiscoverProject(t *testing.T) {
 									TestConfigFilePath:  mustAbs(t, "../testdata/simple-project/images/dotnet/8/node/test.yml.gotpl"),
 									TagSuffix:           "-node",
 									Versions:            model.Versions{"nodejs": "24"},
+									Secrets: model.Secrets{
+										"npm_token": {SourceType: "plain", Value: "npm-token"},
+									},
 								},
 							},
 							Tags: map[string]*model.Tag{
@@ -235,3 +243,143 @@ func TestDiscoverProject_DependencyProject(t *testing.T) {
 		}
 	})
 }
+
+func TestDiscoverProject_ConfiguredProject(t *testing.T) {
+	project, err := DiscoverProject(t.Context(), "../testdata/configured-project")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("parses hive config", func(t *testing.T) {
+		expected := &model.HiveProjectConfig{
+			Buildkit: model.BuildkitConfig{
+				Address: "tcp://127.0.0.1:8502",
+			},
+			Cache: &model.CacheConfig{
+				Type: "s3",
+				Key:  "configured-project",
+				S3: &model.S3CacheConfig{
+					EndpointUrl: "http://127.0.0.1:39505",
+					Bucket:      "buildkit-cache",
+					Region:      "garage",
+					AccessKeyId: &model.Secret{
+						SourceType: "env",
+						Value:      "${S3_ACCESS_KEY_ID}",
+					},
+					SecretAccessKey: &model.Secret{
+						SourceType: "env",
+						Value:      "${S3_SECRET_ACCESS_KEY}",
+					},
+					UsePathStyle: true,
+				},
+			},
+			Registries: model.RegistriesConfig{
+				Staging: "registry.example.com/staging",
+				Publish: "registry.example.com/library",
+			},
+			Platforms: []string{"linux/amd64", "linux/arm64"},
+			DistDir:   "out/dist",
+			ReportDir: "out/reports",
+			Versions:  model.Versions{"alpine": "3.21", "tini": "0.19.0"},
+			BuildArgs: model.BuildArgs{"vendor": "acme-corp"},
+		}
+		if diff := cmp.Diff(expected, project.Config); diff != "" {
+			t.Errorf("Config mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("image values override project defaults", func(t *testing.T) {
+		image := project.ImagesByIdentifier["app"]
+		if image == nil {
+			t.Fatal("app image not found")
+		}
+		expectedVersions := model.Versions{"alpine": "3.20", "tini": "0.19.0"}
+		if diff := cmp.Diff(expectedVersions, image.Versions); diff != "" {
+			t.Errorf("Versions mismatch (-expected +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(model.BuildArgs{"app": "true"}, image.BuildArgs); diff != "" {
+			t.Errorf("BuildArgs mismatch (-expected +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(model.BuildArgs{"vendor": "acme-corp"}, image.DefaultBuildArgs); diff != "" {
+			t.Errorf("DefaultBuildArgs mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("platforms", func(t *testing.T) {
+		image := project.ImagesByIdentifier["app"]
+		if diff := cmp.Diff([]string{"linux/amd64", "linux/arm64"}, image.Platforms); diff != "" {
+			t.Errorf("image should inherit project platforms (-expected +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"linux/arm64"}, image.Tags["edge"].Platforms); diff != "" {
+			t.Errorf("Tag platforms mismatch (-expected +got):\n%s", diff)
+		}
+		if platforms := image.Tags["latest"].Platforms; platforms != nil {
+			t.Errorf("expected no tag platforms, got %v", platforms)
+		}
+	})
+}
+
+func TestApplyProjectDefaults_KeepsImagePlatforms(t *testing.T) {
+	image := &model.Image{Platforms: []string{"linux/arm64"}}
+	applyProjectDefaults(image, &model.HiveProjectConfig{Platforms: []string{"linux/amd64"}})
+	if diff := cmp.Diff([]string{"linux/arm64"}, image.Platforms); diff != "" {
+		t.Errorf("Platforms mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestParseHiveConfigFile(t *testing.T) {
+	writeConfig := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "hive.yml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("empty file yields empty config", func(t *testing.T) {
+		config, err := parseHiveConfigFile(writeConfig(t, ""))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(&model.HiveProjectConfig{}, config); diff != "" {
+			t.Errorf("config mismatch (-expected +got):\n%s", diff)
+		}
+	})
+
+	t.Run("rejects unknown fields", func(t *testing.T) {
+		_, err := parseHiveConfigFile(writeConfig(t, "buildkit:\n  adress: tcp://localhost:1234\n"))
+		if err == nil {
+			t.Fatal("expected error for unknown field")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := parseHiveConfigFile(filepath.Join(t.TempDir(), "hive.yml"))
+		if err == nil {
+			t.Fatal("expected error for missing file")
+		}
+	})
+}
+
+func TestApplyProjectDefaults_SourceDateEpoch(t *testing.T) {
+	projectEpoch, imageEpoch := int64(1700000000), int64(1600000000)
+
+	image := &model.Image{}
+	applyProjectDefaults(image, &model.HiveProjectConfig{SourceDateEpoch: &projectEpoch})
+	if image.SourceDateEpoch == nil || *image.SourceDateEpoch != projectEpoch {
+		t.Errorf("expected project epoch, got %v", image.SourceDateEpoch)
+	}
+
+	image = &model.Image{SourceDateEpoch: &imageEpoch}
+	applyProjectDefaults(image, &model.HiveProjectConfig{SourceDateEpoch: &projectEpoch})
+	if *image.SourceDateEpoch != imageEpoch {
+		t.Errorf("expected image epoch to take precedence, got %d", *image.SourceDateEpoch)
+	}
+
+	image = &model.Image{}
+	applyProjectDefaults(image, &model.HiveProjectConfig{})
+	if image.SourceDateEpoch != nil {
+		t.Errorf("expected no epoch, got %d", *image.SourceDateEpoch)
+	}
+}
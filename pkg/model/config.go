@@ -18,20 +18,79 @@ type SecretValue struct {
 }
 
 type VariantConfig struct {
-	Name      string    `yaml:"name" json:"name" jsonschema:"Name of the variant"`
-	TagSuffix string    `yaml:"tag_suffix" json:"tag_suffix" jsonschema:"Suffix to append to the tag name for this variant"`
-	Versions  Versions  `yaml:"versions" json:"versions,omitempty" jsonschema:"Versions to use for this variant"`
-	BuildArgs BuildArgs `yaml:"build_args" json:"build_args,omitempty" jsonschema:"Build args to add for this variant"`
+	Name        string      `yaml:"name" json:"name" jsonschema:"Name of the variant"`
+	TagSuffix   string      `yaml:"tag_suffix" json:"tag_suffix" jsonschema:"Suffix to append to the tag name for this variant"`
+	Versions    Versions    `yaml:"versions" json:"versions,omitempty" jsonschema:"Versions to use for this variant"`
+	BuildArgs   BuildArgs   `yaml:"build_args" json:"build_args,omitempty" jsonschema:"Build args to add for this variant"`
+	Platforms   []string    `yaml:"platforms" json:"platforms,omitempty" jsonschema:"Platforms to build this variant for, overrides the tag and image platforms"`
+	Secrets     Secrets     `yaml:"secrets" json:"secrets,omitempty" jsonschema:"Secrets to resolve for this variant only, overrides tag and image secrets with the same name"`
+	Labels      Labels      `yaml:"labels" json:"labels,omitempty" jsonschema:"Labels to set for this variant, overrides tag and image labels with the same name"`
+	Annotations Annotations `yaml:"annotations" json:"annotations,omitempty" jsonschema:"Annotations to set for this variant, overrides tag and image annotations with the same name"`
+}
+
+type FloatingTagsConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty" jsonschema:"Publish floating tags like 3.13 and 3 for the highest version sharing that prefix"`
+	Latest  bool `yaml:"latest" json:"latest,omitempty" jsonschema:"Also publish latest for the highest version of the image"`
 }
 
 type ImageDefinitionConfig struct {
-	Tags      []*Tag          `yaml:"tags" json:"tags" jsonschema:"Tags to create for this image"`
-	Variants  []VariantConfig `yaml:"variants" json:"variants,omitempty" jsonschema:"Variants to create for this image"`
-	Versions  Versions        `yaml:"versions" json:"versions,omitempty" jsonschema:"Versions to use for this image"`
-	BuildArgs BuildArgs       `yaml:"build_args" json:"build_args,omitempty" jsonschema:"Build args to add for this image"`
-	Secrets   Secrets         `yaml:"secrets" json:"secrets,omitempty" jsonschema:"Secrets to resolve for this image"`
-	DependsOn []string        `yaml:"depends_on" json:"depends_on,omitempty" jsonschema:"Names of other images in this project that must be built before this image"`
+	Tags            []*Tag             `yaml:"tags" json:"tags" jsonschema:"Tags to create for this image"`
+	Variants        []VariantConfig    `yaml:"variants" json:"variants,omitempty" jsonschema:"Variants to create for this image"`
+	Versions        Versions           `yaml:"versions" json:"versions,omitempty" jsonschema:"Versions to use for this image"`
+	BuildArgs       BuildArgs          `yaml:"build_args" json:"build_args,omitempty" jsonschema:"Build args to add for this image"`
+	Secrets         Secrets            `yaml:"secrets" json:"secrets,omitempty" jsonschema:"Secrets to resolve for this image"`
+	DependsOn       []string           `yaml:"depends_on" json:"depends_on,omitempty" jsonschema:"Names of other images in this project that must be built before this image"`
+	Platforms       []string           `yaml:"platforms" json:"platforms,omitempty" jsonschema:"Platforms to build this image for, e.g. linux/amd64, overrides the project platforms"`
+	FloatingTags    FloatingTagsConfig `yaml:"floating_tags" json:"floating_tags,omitempty" jsonschema:"Floating tags to publish in addition to the tags of this image"`
+	SourceDateEpoch *int64             `yaml:"source_date_epoch" json:"source_date_epoch,omitempty" jsonschema:"Unix timestamp to use as SOURCE_DATE_EPOCH for this image, overrides the project value and the timestamp of the last commit touching the image directory"`
+	Labels          Labels             `yaml:"labels" json:"labels,omitempty" jsonschema:"Labels to set on the image in addition to the generated org.opencontainers.image labels, values support templates"`
+	Annotations     Annotations        `yaml:"annotations" json:"annotations,omitempty" jsonschema:"Annotations to set on the OCI manifest and index in addition to the generated org.opencontainers.image annotations, values support templates"`
+}
+
+type BuildkitConfig struct {
+	Address string `yaml:"address" json:"address,omitempty" jsonschema:"Address of the buildkit daemon, e.g. tcp://127.0.0.1:1234 or unix:///run/buildkit/buildkitd.sock"`
+}
+
+type S3CacheConfig struct {
+	EndpointUrl     string  `yaml:"endpoint_url" json:"endpoint_url,omitempty" jsonschema:"Endpoint URL of the S3-compatible storage"`
+	Bucket          string  `yaml:"bucket" json:"bucket" jsonschema:"Bucket to store the cache in"`
+	Region          string  `yaml:"region" json:"region,omitempty" jsonschema:"Region of the bucket"`
+	AccessKeyId     *Secret `yaml:"access_key_id" json:"access_key_id,omitempty" jsonschema:"Access key id, resolved like image secrets"`
+	SecretAccessKey *Secret `yaml:"secret_access_key" json:"secret_access_key,omitempty" jsonschema:"Secret access key, resolved like image secrets"`
+	UsePathStyle    bool    `yaml:"use_path_style" json:"use_path_style,omitempty" jsonschema:"Use path style instead of virtual hosted style bucket access"`
+}
+
+type RegistryCacheConfig struct {
+	Ref      string `yaml:"ref" json:"ref" jsonschema:"Repository to store the cache in, tagged with the cache key"`
+	Insecure bool   `yaml:"insecure" json:"insecure,omitempty" jsonschema:"Allow plain HTTP connections to the registry"`
+}
+
+type LocalCacheConfig struct {
+	Dir string `yaml:"dir" json:"dir" jsonschema:"Directory to store the cache in, relative to the project root"`
+}
+
+type CacheConfig struct {
+	Type     string               `yaml:"type" json:"type" jsonschema:"Type of the cache backend (s3, registry, local, inline)"`
+	Key      string               `yaml:"key" json:"key,omitempty" jsonschema:"Name of the cache entry per tag and variant, a Go template over ImageName, Tag and Variant, defaults to one entry per tag and variant"`
+	Mode     string               `yaml:"mode" json:"mode,omitempty" jsonschema:"Whether to import and export the cache (read-write, read-only, write-only), defaults to read-write"`
+	S3       *S3CacheConfig       `yaml:"s3" json:"s3,omitempty" jsonschema:"Configuration for the s3 cache backend"`
+	Registry *RegistryCacheConfig `yaml:"registry" json:"registry,omitempty" jsonschema:"Configuration for the registry cache backend"`
+	Local    *LocalCacheConfig    `yaml:"local" json:"local,omitempty" jsonschema:"Configuration for the local cache backend"`
+}
+
+type RegistriesConfig struct {
+	Staging string `yaml:"staging" json:"staging,omitempty" jsonschema:"Registry to stage __hive__ base images in on CI, locally an embedded registry is used"`
+	Publish string `yaml:"publish" json:"publish,omitempty" jsonschema:"Registry to publish built images to"`
 }
 
 type HiveProjectConfig struct {
+	Buildkit        BuildkitConfig   `yaml:"buildkit" json:"buildkit,omitempty" jsonschema:"BuildKit daemon to use for builds"`
+	Cache           *CacheConfig     `yaml:"cache" json:"cache,omitempty" jsonschema:"Cache backend to use for builds"`
+	Registries      RegistriesConfig `yaml:"registries" json:"registries,omitempty" jsonschema:"Registries used for staging and publishing images"`
+	Platforms       []string         `yaml:"platforms" json:"platforms,omitempty" jsonschema:"Default platforms to build images for, e.g. linux/amd64"`
+	DistDir         string           `yaml:"dist_dir" json:"dist_dir,omitempty" jsonschema:"Directory to render the project to, relative to the project root"`
+	ReportDir       string           `yaml:"report_dir" json:"report_dir,omitempty" jsonschema:"Directory to write reports to, relative to the project root"`
+	Versions        Versions         `yaml:"versions" json:"versions,omitempty" jsonschema:"Versions to use for all images, can be overridden per image"`
+	BuildArgs       BuildArgs        `yaml:"build_args" json:"build_args,omitempty" jsonschema:"Build args to add for all images, can be overridden per image"`
+	SourceDateEpoch *int64           `yaml:"source_date_epoch" json:"source_date_epoch,omitempty" jsonschema:"Unix timestamp to use as SOURCE_DATE_EPOCH for all images, defaults to the timestamp of the last commit touching the image directory"`
 }
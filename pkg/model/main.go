@@ -4,10 +4,20 @@ type Versions map[string]string
 
 type BuildArgs map[string]string
 
+// Labels are set on the image config, values are rendered as templates
+type Labels map[string]string
+
+// Annotations are set on the OCI manifest and index, values are rendered as templates
+type Annotations map[string]string
+
 type Tag struct {
-	Name      string    `yaml:"name" json:"name" jsonschema:"Name of the tag"`
-	Versions  Versions  `yaml:"versions" json:"versions,omitempty" jsonschema:"Versions to use for this tag"`
-	BuildArgs BuildArgs `yaml:"build_args" json:"build_args,omitempty" jsonschema:"Build args to specify for this tag"`
+	Name        string      `yaml:"name" json:"name" jsonschema:"Name of the tag"`
+	Versions    Versions    `yaml:"versions" json:"versions,omitempty" jsonschema:"Versions to use for this tag"`
+	BuildArgs   BuildArgs   `yaml:"build_args" json:"build_args,omitempty" jsonschema:"Build args to specify for this tag"`
+	Platforms   []string    `yaml:"platforms" json:"platforms,omitempty" jsonschema:"Platforms to build this tag for, overrides the image platforms"`
+	Secrets     Secrets     `yaml:"secrets" json:"secrets,omitempty" jsonschema:"Secrets to resolve for this tag, overrides image secrets with the same name"`
+	Labels      Labels      `yaml:"labels" json:"labels,omitempty" jsonschema:"Labels to set for this tag, overrides image labels with the same name"`
+	Annotations Annotations `yaml:"annotations" json:"annotations,omitempty" jsonschema:"Annotations to set for this tag, overrides image annotations with the same name"`
 }
 
 type Image struct {
@@ -20,10 +30,18 @@ type Image struct {
 	BuildEntryPointPath string
 	Versions            Versions
 	BuildArgs           BuildArgs `yaml:"build_args"`
-	Secrets             Secrets   `yaml:"secrets"`
-	Tags                map[string]*Tag
-	Variants            map[string]*ImageVariant
-	DependsOn           []string
+	// DefaultBuildArgs are the project-wide build args, overridden by image, tag and variant build args
+	DefaultBuildArgs BuildArgs `yaml:"-"`
+	Secrets          Secrets   `yaml:"secrets"`
+	Platforms        []string
+	FloatingTags     FloatingTagsConfig
+	Tags             map[string]*Tag
+	Variants         map[string]*ImageVariant
+	DependsOn        []string
+	Labels           Labels
+	Annotations      Annotations
+	// SourceDateEpoch is the configured SOURCE_DATE_EPOCH, nil if it is derived from the git history
+	SourceDateEpoch *int64
 }
 
 type ImageVariant struct {
@@ -35,11 +53,16 @@ type ImageVariant struct {
 	TestConfigFilePath  string
 	Versions            Versions
 	BuildArgs           BuildArgs `yaml:"build_args"`
+	Secrets             Secrets   `yaml:"secrets"`
+	Platforms           []string
+	Labels              Labels
+	Annotations         Annotations
 }
 
 type ContainerHiveProject struct {
 	RootDir            string
 	ConfigFilePath     string
+	Config             *HiveProjectConfig
 	ImagesByIdentifier map[string]*Image
 	ImagesByName       map[string][]*Image
 }
@@ -65,12 +65,8 @@ func setupImageTagDir(tagPath string, image *model.Image, tag *model.Tag) error
 		return errors.Join(errors.New("failed to create tag directory"), err)
 	}
 
-	resolved, err := buildconfig_resolver.ForTag(image, tag)
-	if err != nil {
-		return errors.Join(errors.New("failed to resolve build configuration"), err)
-	}
-
-	tmplCtx := newTemplateContext(image, resolved)
+	// Secrets are not available in templates, they are only resolved for the build
+	tmplCtx := newTemplateContext(image, buildconfig_resolver.ArgsForTag(image, tag))
 
 	if image.BuildEntryPointPath != "" {
 		// Strip template extension for output filename
@@ -100,12 +96,7 @@ func setupImageTagDir(tagPath string, image *model.Image, tag *model.Tag) error
 }
 
 func setupVariantDir(variantPath string, image *model.Image, tag *model.Tag, variantDef *model.ImageVariant) error {
-	resolved, err := buildconfig_resolver.ForTagVariant(image, variantDef, tag)
-	if err != nil {
-		return errors.Join(errors.New("failed to resolve build configuration for variant"), err)
-	}
-
-	tmplCtx := newTemplateContext(image, resolved)
+	tmplCtx := newTemplateContext(image, buildconfig_resolver.ArgsForTagVariant(image, variantDef, tag))
 
 	if err := mkdir(variantPath); err != nil {
 		return errors.Join(errors.New("failed to create variant directory"), err)